@@ -0,0 +1,127 @@
+package abci
+
+import (
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+/*
+	PrepareProposal and ProcessProposal must agree on whether a required bid
+	fits in the block, or an honest proposer could be rejected for omitting a
+	bid it had no room for. Both measure proposals with blockSpace against the
+	BlockLimits derived from the consensus params.
+
+	CometBFT hands the proposer MaxTxBytes, which depends on the evidence it
+	has pending and is unknown to other validators. BlockLimits instead
+	reserve the whole evidence budget, and size the last commit by its votes,
+	so every validator computes the same bound. The proposer includes required
+	txs within both bounds, which is never more than validators expect unless
+	the validator set grew at H while the evidence budget was nearly used.
+*/
+
+// BlockLimits bound the txs of a proposal. A non-positive MaxTxBytes or
+// MaxGas leaves that dimension unbounded.
+type BlockLimits struct {
+	MaxTxBytes int64
+	MaxGas     int64
+}
+
+// ProposalLimits returns the limits every validator applies to a proposal
+// whose last commit holds lastCommitVotes votes
+func ProposalLimits(cp cmtproto.ConsensusParams, lastCommitVotes int) BlockLimits {
+	var limits BlockLimits
+	if cp.Block == nil {
+		return limits
+	}
+	limits.MaxGas = cp.Block.MaxGas
+
+	maxBytes := cp.Block.MaxBytes
+	if maxBytes == -1 {
+		maxBytes = cmttypes.MaxBlockSizeBytes
+	}
+	if maxBytes <= 0 {
+		return limits
+	}
+	var evidenceBytes int64
+	if cp.Evidence != nil {
+		evidenceBytes = cp.Evidence.MaxBytes
+	}
+
+	// As cmttypes.MaxDataBytes, without panicking on params too small for a block
+	limits.MaxTxBytes = maxBytes -
+		cmttypes.MaxOverheadForBlock -
+		cmttypes.MaxHeaderBytes -
+		cmttypes.MaxCommitBytes(lastCommitVotes) -
+		evidenceBytes
+	if limits.MaxTxBytes <= 0 {
+		// No tx fits, rather than no bound
+		limits.MaxTxBytes = 1
+	}
+	return limits
+}
+
+// without returns the limits left for the other txs once txBytes, e.g. the
+// special tx, took their space. Taking all of it leaves no room, not no bound.
+func (l BlockLimits) without(txBytes []byte) BlockLimits {
+	if l.MaxTxBytes <= 0 {
+		return l
+	}
+	l.MaxTxBytes -= txSize(txBytes)
+	if l.MaxTxBytes <= 0 {
+		l.MaxTxBytes = 1
+	}
+	return l
+}
+
+// blockSpace tracks the bytes and gas used by a proposal as txs are added. A
+// non-positive maxGas leaves gas unbounded.
+type blockSpace struct {
+	maxGas int64
+	bytes  int64
+	gas    uint64
+}
+
+// fill adds the lane's txs in order while they fit under limit bytes,
+// returning those added
+func (s *blockSpace) fill(lane []encodedTx, limit int64) []encodedTx {
+	var txs []encodedTx
+	for _, tx := range lane {
+		if s.add(tx.bz, tx.gas, limit) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// add counts txBytes and gas against the proposal unless that would take it
+// past limit bytes or the block gas limit
+func (s *blockSpace) add(txBytes []byte, gas uint64, limit int64) bool {
+	if !s.fits(txBytes, gas, limit) {
+		return false
+	}
+	s.use(txBytes, gas)
+	return true
+}
+
+// fits reports whether txBytes and gas still fit under limit bytes and the
+// block gas limit. Bytes are measured as CometBFT does, including each tx's
+// encoding overhead in the block data.
+func (s *blockSpace) fits(txBytes []byte, gas uint64, limit int64) bool {
+	if s.bytes+txSize(txBytes) > limit {
+		return false
+	}
+	if s.maxGas > 0 && (gas > uint64(s.maxGas) || s.gas > uint64(s.maxGas)-gas) {
+		return false
+	}
+	return true
+}
+
+// use counts txBytes and gas against the proposal whether or not they fit
+func (s *blockSpace) use(txBytes []byte, gas uint64) {
+	s.bytes += txSize(txBytes)
+	s.gas += gas
+}
+
+func txSize(txBytes []byte) int64 {
+	return cmttypes.ComputeProtoSizeForTxs([]cmttypes.Tx{txBytes})
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	"github.com/spf13/cobra"
+)
+
+// GetQueryCmd returns the commands reading the special transaction store
+func GetQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "specialtx",
+		Short:                      "Query the special transaction store",
+		DisableFlagParsing:         true,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetCmdQueryLast(),
+	)
+
+	return cmd
+}
+
+func GetCmdQueryLast() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "last",
+		Short: "Show the vote extension tally of the last finalized special transaction",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := abciv1.NewQueryClient(clientCtx).LastSpecialTransaction(cmd.Context(), &abciv1.QueryLastSpecialTransactionRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
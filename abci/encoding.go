@@ -0,0 +1,147 @@
+package abci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	"io"
+)
+
+/*
+	AppVoteExtension and SpecialTransaction are encoded as the messages in
+	proto/cosmapp/abci/v1/types.proto, generated into abci/types. Decoding is
+	canonical: bytes are only accepted if encoding the decoded message gives
+	them back, so unknown fields, repeated fields and non-minimal encodings are
+	rejected and no two encodings carry the same data.
+
+	Releases before the protobuf format encoded both types as a JSON object
+	holding a height and a list of bids. Vote extensions are still decoded from
+	that legacy form, reported as version 0, so validators on different
+	releases can verify each other's extensions across an upgrade. Callers only
+	accept it within the migration window, see LegacyVoteExtensionsAllowed.
+	Legacy special transactions carry no extended commit that ProcessProposal
+	could verify, so they are rejected.
+*/
+
+const (
+	// EncodingVersion is the wire version written by this release
+	EncodingVersion uint32 = 1
+	// LegacyJSONVersion is reported for data decoded from the legacy JSON form
+	LegacyJSONVersion uint32 = 0
+)
+
+// legacyJSON is the pre-protobuf encoding of both types, only decoded for
+// vote extensions
+type legacyJSON struct {
+	Height int64
+	Bids   [][]byte
+}
+
+func (ve AppVoteExtension) Marshal() ([]byte, error) {
+	msg := abciv1.AppVoteExtension{
+		Version: EncodingVersion,
+		Height:  ve.Height,
+		Txs:     ve.Txs,
+	}
+	return msg.Marshal()
+}
+
+// Size returns the length of the encoded vote extension
+func (ve AppVoteExtension) Size() int {
+	msg := abciv1.AppVoteExtension{
+		Version: EncodingVersion,
+		Height:  ve.Height,
+		Txs:     ve.Txs,
+	}
+	return msg.Size()
+}
+
+// voteExtTxSize returns the number of bytes tx adds to an encoded vote extension
+func voteExtTxSize(tx []byte) int {
+	msg := abciv1.AppVoteExtension{Txs: [][]byte{tx}}
+	return msg.Size()
+}
+
+func UnmarshalVoteExtension(bz []byte) (AppVoteExtension, error) {
+	if isLegacyJSON(bz) {
+		legacy, err := unmarshalLegacyJSON(bz)
+		if err != nil {
+			return AppVoteExtension{}, fmt.Errorf("unable to decode legacy vote extension: %w", err)
+		}
+		return AppVoteExtension{Version: LegacyJSONVersion, Height: legacy.Height, Bids: legacy.Bids}, nil
+	}
+
+	var msg abciv1.AppVoteExtension
+	if err := unmarshalCanonical(bz, &msg); err != nil {
+		return AppVoteExtension{}, fmt.Errorf("unable to decode vote extension: %w", err)
+	}
+	if msg.Version == LegacyJSONVersion || msg.Version > EncodingVersion {
+		return AppVoteExtension{}, fmt.Errorf("unsupported vote extension version %d", msg.Version)
+	}
+	return AppVoteExtension{Version: msg.Version, Height: msg.Height, Txs: msg.Txs}, nil
+}
+
+func (st SpecialTransaction) Marshal() ([]byte, error) {
+	msg := abciv1.SpecialTransaction{
+		Version:            EncodingVersion,
+		Height:             int64(st.Height),
+		ExtendedCommitInfo: st.ExtendedCommitInfo,
+	}
+	return msg.Marshal()
+}
+
+func UnmarshalSpecialTransaction(bz []byte) (SpecialTransaction, error) {
+	var msg abciv1.SpecialTransaction
+	if err := unmarshalCanonical(bz, &msg); err != nil {
+		return SpecialTransaction{}, fmt.Errorf("unable to decode special transaction: %w", err)
+	}
+	if msg.Version == LegacyJSONVersion || msg.Version > EncodingVersion {
+		return SpecialTransaction{}, fmt.Errorf("unsupported special transaction version %d", msg.Version)
+	}
+	return SpecialTransaction{
+		Version:            msg.Version,
+		Height:             int(msg.Height),
+		ExtendedCommitInfo: msg.ExtendedCommitInfo,
+	}, nil
+}
+
+type protoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// unmarshalCanonical decodes bz into msg, failing unless bz is exactly how
+// msg encodes
+func unmarshalCanonical(bz []byte, msg protoMessage) error {
+	if err := msg.Unmarshal(bz); err != nil {
+		return err
+	}
+	canonical, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bz, canonical) {
+		return fmt.Errorf("non-canonical encoding")
+	}
+	return nil
+}
+
+// isLegacyJSON reports whether bz holds a pre-protobuf JSON object. A leading
+// '{' decodes as a group tag for field 15, which neither message uses.
+func isLegacyJSON(bz []byte) bool {
+	return len(bz) > 0 && bz[0] == '{'
+}
+
+func unmarshalLegacyJSON(bz []byte) (legacyJSON, error) {
+	var legacy legacyJSON
+	dec := json.NewDecoder(bytes.NewReader(bz))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&legacy); err != nil {
+		return legacyJSON{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return legacyJSON{}, fmt.Errorf("unexpected data after legacy JSON object")
+	}
+	return legacy, nil
+}
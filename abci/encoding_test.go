@@ -0,0 +1,112 @@
+package abci
+
+import (
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"testing"
+)
+
+// Vote extension and special transaction written by the JSON encoding of the
+// baseline release, both holding the bids "bid1" and "bid2"
+const (
+	baselineVoteExtension      = `{"Height":10,"Bids":["YmlkMQ==","YmlkMg=="]}`
+	baselineSpecialTransaction = `{"Height":9,"Bids":["YmlkMQ==","YmlkMg=="]}`
+)
+
+func TestVoteExtensionEncoding(t *testing.T) {
+	ve := AppVoteExtension{
+		Height: 10,
+		Txs:    [][]byte{[]byte("tx1"), []byte("tx2")},
+	}
+
+	bz, err := ve.Marshal()
+	require.NoError(t, err)
+
+	// Encoding is deterministic
+	bz2, err := ve.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, bz, bz2)
+
+	decoded, err := UnmarshalVoteExtension(bz)
+	require.NoError(t, err)
+	require.Equal(t, EncodingVersion, decoded.Version)
+	require.Equal(t, ve.Height, decoded.Height)
+	require.Equal(t, ve.Txs, decoded.Txs)
+
+	// Unknown fields are rejected
+	withUnknown := protowire.AppendTag(append([]byte{}, bz...), 9, protowire.BytesType)
+	withUnknown = protowire.AppendBytes(withUnknown, []byte("future"))
+	_, err = UnmarshalVoteExtension(withUnknown)
+	require.Error(t, err)
+
+	// So is a second value for a non-repeated field
+	repeated := protowire.AppendTag(append([]byte{}, bz...), 2, protowire.VarintType)
+	repeated = protowire.AppendVarint(repeated, 11)
+	_, err = UnmarshalVoteExtension(repeated)
+	require.Error(t, err)
+
+	// Unsupported versions are rejected
+	future := protowire.AppendTag(nil, 1, protowire.VarintType)
+	future = protowire.AppendVarint(future, uint64(EncodingVersion+1))
+	_, err = UnmarshalVoteExtension(future)
+	require.Error(t, err)
+
+	_, err = UnmarshalVoteExtension([]byte{0xff, 0xff})
+	require.Error(t, err)
+}
+
+func TestSpecialTransactionEncoding(t *testing.T) {
+	st := SpecialTransaction{
+		Height: 9,
+		ExtendedCommitInfo: abci.ExtendedCommitInfo{
+			Round: 1,
+			Votes: []abci.ExtendedVoteInfo{
+				{
+					Validator:          abci.Validator{Address: []byte("val1"), Power: 10},
+					VoteExtension:      []byte("ve"),
+					ExtensionSignature: []byte("sig"),
+					BlockIdFlag:        cmtproto.BlockIDFlagCommit,
+				},
+			},
+		},
+	}
+
+	bz, err := st.Marshal()
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalSpecialTransaction(bz)
+	require.NoError(t, err)
+	require.Equal(t, EncodingVersion, decoded.Version)
+	require.Equal(t, st.Height, decoded.Height)
+	require.Equal(t, st.ExtendedCommitInfo, decoded.ExtendedCommitInfo)
+
+	// A repeated extended commit would merge into one, it is rejected instead
+	repeated := protowire.AppendTag(append([]byte{}, bz...), 3, protowire.BytesType)
+	repeated = protowire.AppendBytes(repeated, nil)
+	_, err = UnmarshalSpecialTransaction(repeated)
+	require.Error(t, err)
+
+	// A regular sdk tx in the first slot is not a special transaction
+	_, err = UnmarshalSpecialTransaction([]byte{0x0a, 0x02, 0x01})
+	require.Error(t, err)
+}
+
+func TestLegacyJSONEncoding(t *testing.T) {
+	bids := [][]byte{[]byte("bid1"), []byte("bid2")}
+
+	ve, err := UnmarshalVoteExtension([]byte(baselineVoteExtension))
+	require.NoError(t, err)
+	require.Equal(t, AppVoteExtension{Version: LegacyJSONVersion, Height: 10, Bids: bids}, ve)
+
+	// Only the baseline fields are accepted
+	_, err = UnmarshalVoteExtension([]byte(`{"Height":10,"Bids":[],"Extra":1}`))
+	require.Error(t, err)
+	_, err = UnmarshalVoteExtension([]byte(baselineVoteExtension + `{}`))
+	require.Error(t, err)
+
+	// A legacy special transaction has no extended commit to verify
+	_, err = UnmarshalSpecialTransaction([]byte(baselineSpecialTransaction))
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package abci
+
+import (
+	"cosmossdk.io/math"
+	"encoding/json"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+)
+
+/*
+	The bid inclusion threshold has a node level default, read from the
+	[vote-extensions] section of app.toml, and an on-chain param. The param is
+	seeded at genesis from the "voteext" section of app_state, e.g.
+
+	"voteext": {"bid_threshold": "0.667000000000000000"}
+
+	and set or changed through a governance param change proposal, e.g.
+
+	{"subspace": "voteext", "key": "BidThreshold", "value": "\"0.667000000000000000\""}
+
+	Once the param is set every validator uses it, so the rule ProcessProposal
+	applies is the same across the network. Until then validators must agree
+	on their node default.
+
+	LegacyVoteExtHeight is the last height whose vote extensions may still use
+	the legacy JSON encoding. The upgrade to the protobuf encoding sets it a
+	LegacyVoteExtWindow past the upgrade height; chains that never ran the
+	JSON release leave it at 0 and accept none.
+*/
+
+// ParamsSubspace is the x/params subspace holding vote extension parameters,
+// and the app_state key of their genesis state
+const ParamsSubspace = "voteext"
+
+// LegacyVoteExtWindow is the number of blocks after the upgrade to the
+// protobuf encoding in which legacy JSON vote extensions are still accepted
+const LegacyVoteExtWindow int64 = 10
+
+var (
+	KeyBidThreshold        = []byte("BidThreshold")
+	KeyLegacyVoteExtHeight = []byte("LegacyVoteExtHeight")
+)
+
+func ParamKeyTable() paramstypes.KeyTable {
+	return paramstypes.NewKeyTable(
+		paramstypes.NewParamSetPair(KeyBidThreshold, math.LegacyDec{}, ValidateBidThreshold),
+		paramstypes.NewParamSetPair(KeyLegacyVoteExtHeight, int64(0), ValidateLegacyVoteExtHeight),
+	)
+}
+
+// ValidateBidThreshold checks the threshold is a fraction in [0, 1)
+func ValidateBidThreshold(i interface{}) error {
+	threshold, ok := i.(math.LegacyDec)
+	if !ok {
+		return fmt.Errorf("invalid bid threshold type: %T", i)
+	}
+	if threshold.IsNil() || threshold.IsNegative() || threshold.GTE(math.LegacyOneDec()) {
+		return fmt.Errorf("bid threshold must be in [0, 1): %v", threshold)
+	}
+	return nil
+}
+
+// BidThreshold returns the on-chain bid threshold if one is set, falling back
+// to the node's configured default.
+func BidThreshold(ctx sdk.Context, ps paramstypes.Subspace, fallback math.LegacyDec) math.LegacyDec {
+	if threshold := onChainBidThreshold(ctx, ps); threshold != nil {
+		return *threshold
+	}
+	return fallback
+}
+
+func onChainBidThreshold(ctx sdk.Context, ps paramstypes.Subspace) *math.LegacyDec {
+	if !ps.HasKeyTable() {
+		return nil
+	}
+
+	var threshold math.LegacyDec
+	ps.GetIfExists(ctx, KeyBidThreshold, &threshold)
+	if threshold.IsNil() {
+		return nil
+	}
+	return &threshold
+}
+
+// GenesisState holds the vote extension params in app_state. A param left
+// out is not set on-chain.
+type GenesisState struct {
+	BidThreshold *math.LegacyDec `json:"bid_threshold,omitempty"`
+}
+
+// DefaultGenesisState leaves the bid threshold to the nodes' default
+func DefaultGenesisState() GenesisState {
+	return GenesisState{}
+}
+
+// InitGenesis sets the params from their app_state section, bz
+func InitGenesis(ctx sdk.Context, ps paramstypes.Subspace, bz json.RawMessage) error {
+	gs := DefaultGenesisState()
+	if len(bz) > 0 {
+		if err := json.Unmarshal(bz, &gs); err != nil {
+			return fmt.Errorf("invalid %s genesis state: %w", ParamsSubspace, err)
+		}
+	}
+	if gs.BidThreshold == nil {
+		return nil
+	}
+	if err := ValidateBidThreshold(*gs.BidThreshold); err != nil {
+		return fmt.Errorf("invalid %s genesis state: %w", ParamsSubspace, err)
+	}
+
+	ps.Set(ctx, KeyBidThreshold, *gs.BidThreshold)
+	return nil
+}
+
+// ExportGenesis returns the params' app_state section
+func ExportGenesis(ctx sdk.Context, ps paramstypes.Subspace) (json.RawMessage, error) {
+	return json.Marshal(GenesisState{BidThreshold: onChainBidThreshold(ctx, ps)})
+}
+
+// ValidateLegacyVoteExtHeight checks the height is not negative
+func ValidateLegacyVoteExtHeight(i interface{}) error {
+	height, ok := i.(int64)
+	if !ok {
+		return fmt.Errorf("invalid legacy vote extension height type: %T", i)
+	}
+	if height < 0 {
+		return fmt.Errorf("legacy vote extension height must not be negative: %d", height)
+	}
+	return nil
+}
+
+// LegacyVoteExtensionsAllowed reports whether vote extensions from height may
+// use the legacy JSON encoding, i.e. height is within the migration window.
+func LegacyVoteExtensionsAllowed(ctx sdk.Context, ps paramstypes.Subspace, height int64) bool {
+	if !ps.HasKeyTable() {
+		return false
+	}
+
+	var until int64
+	ps.GetIfExists(ctx, KeyLegacyVoteExtHeight, &until)
+	return height <= until
+}
@@ -0,0 +1,89 @@
+package abci
+
+import (
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	"encoding/json"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBidThreshold(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	key := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	tkey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+	ctx := testutil.DefaultContext(key, tkey)
+	ps := paramstypes.NewSubspace(encCfg.Marshaler, codec.NewLegacyAmino(), key, tkey, ParamsSubspace).
+		WithKeyTable(ParamKeyTable())
+
+	// Without a key table or on-chain value the node's default applies
+	nodeDefault := math.LegacyNewDecWithPrec(6, 1)
+	require.Equal(t, nodeDefault, BidThreshold(ctx, paramstypes.Subspace{}, nodeDefault))
+	require.Equal(t, nodeDefault, BidThreshold(ctx, ps, nodeDefault))
+
+	// Once set on-chain the param overrides it
+	onChain := math.LegacyNewDecWithPrec(667, 3)
+	ps.Set(ctx, KeyBidThreshold, onChain)
+	require.True(t, onChain.Equal(BidThreshold(ctx, ps, nodeDefault)))
+
+	require.NoError(t, ValidateBidThreshold(math.LegacyZeroDec()))
+	require.NoError(t, ValidateBidThreshold(onChain))
+	require.Error(t, ValidateBidThreshold(math.LegacyOneDec()))
+	require.Error(t, ValidateBidThreshold(math.LegacyNewDec(-1)))
+	require.Error(t, ValidateBidThreshold("0.5"))
+}
+
+func TestParamsGenesis(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	key := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	tkey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+	ctx := testutil.DefaultContext(key, tkey)
+	ps := paramstypes.NewSubspace(encCfg.Marshaler, codec.NewLegacyAmino(), key, tkey, ParamsSubspace).
+		WithKeyTable(ParamKeyTable())
+
+	// A genesis file without the threshold leaves it to the nodes' default
+	require.NoError(t, InitGenesis(ctx, ps, nil))
+	require.NoError(t, InitGenesis(ctx, ps, json.RawMessage(`{}`)))
+	require.False(t, ps.Has(ctx, KeyBidThreshold))
+	require.Equal(t, DefaultBidThreshold, BidThreshold(ctx, ps, DefaultBidThreshold))
+	bz, err := ExportGenesis(ctx, ps)
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(bz))
+
+	require.NoError(t, InitGenesis(ctx, ps, json.RawMessage(`{"bid_threshold":"0.667"}`)))
+	require.True(t, math.LegacyNewDecWithPrec(667, 3).Equal(BidThreshold(ctx, ps, DefaultBidThreshold)))
+
+	// The exported section seeds the same threshold
+	bz, err = ExportGenesis(ctx, ps)
+	require.NoError(t, err)
+	require.NoError(t, InitGenesis(ctx, ps, bz))
+	require.True(t, math.LegacyNewDecWithPrec(667, 3).Equal(BidThreshold(ctx, ps, DefaultBidThreshold)))
+
+	require.Error(t, InitGenesis(ctx, ps, json.RawMessage(`{"bid_threshold":"1"}`)))
+	require.Error(t, InitGenesis(ctx, ps, json.RawMessage(`{"bid_threshold":1}`)))
+}
+
+func TestLegacyVoteExtensionsAllowed(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	key := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	tkey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+	ctx := testutil.DefaultContext(key, tkey)
+	ps := paramstypes.NewSubspace(encCfg.Marshaler, codec.NewLegacyAmino(), key, tkey, ParamsSubspace).
+		WithKeyTable(ParamKeyTable())
+
+	// Chains that never ran the JSON release accept no legacy vote extensions
+	require.False(t, LegacyVoteExtensionsAllowed(ctx, paramstypes.Subspace{}, 1))
+	require.False(t, LegacyVoteExtensionsAllowed(ctx, ps, 1))
+
+	ps.Set(ctx, KeyLegacyVoteExtHeight, int64(10))
+	require.True(t, LegacyVoteExtensionsAllowed(ctx, ps, 10))
+	require.False(t, LegacyVoteExtensionsAllowed(ctx, ps, 11))
+
+	require.NoError(t, ValidateLegacyVoteExtHeight(int64(0)))
+	require.Error(t, ValidateLegacyVoteExtHeight(int64(-1)))
+	require.Error(t, ValidateLegacyVoteExtHeight(10))
+}
@@ -0,0 +1,147 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/core/store"
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	"sort"
+)
+
+const (
+	// ModuleName names the special transaction's store and msg authority
+	ModuleName = "specialtx"
+	// StoreKey is the KV store holding the record of the last finalized
+	// special transaction
+	StoreKey = ModuleName
+)
+
+var LastSpecialTxKey = []byte{0x01}
+
+// SpecialTxStore records the special transaction of every finalized block.
+// The PreBlocker tallies its vote extensions before the block's txs run,
+// keeping the tally rather than the extended commit, and, when a promoter is
+// set, promotes the mempool txs whose bids it shows were seen. BaseApp then
+// executes it as MsgSpecialTransaction, see SpecialTxDecoder.
+type SpecialTxStore struct {
+	logger       log.Logger
+	storeService store.KVStoreService
+	txDecoder    sdk.TxDecoder
+	cdc          codec.Codec
+	paramSpace   paramstypes.Subspace
+	// bidThreshold is the node's default, the on-chain param overrides it
+	bidThreshold math.LegacyDec
+	promoter     *BidPromoter
+}
+
+func NewSpecialTxStore(lg log.Logger, ss store.KVStoreService, txDecoder sdk.TxDecoder, cdc codec.Codec, ps paramstypes.Subspace, bidThreshold math.LegacyDec, promoter *BidPromoter) *SpecialTxStore {
+	return &SpecialTxStore{
+		logger:       lg,
+		storeService: ss,
+		txDecoder:    txDecoder,
+		cdc:          cdc,
+		paramSpace:   ps,
+		bidThreshold: bidThreshold,
+		promoter:     promoter,
+	}
+}
+
+func (s *SpecialTxStore) PreBlocker() sdk.PreBlocker {
+	return func(ctx sdk.Context, req *abci.RequestFinalizeBlock) (*sdk.ResponsePreBlock, error) {
+		if !SpecialTxExpected(ctx, req.Height) || len(req.Txs) == 0 {
+			return &sdk.ResponsePreBlock{}, nil
+		}
+
+		st, err := UnmarshalSpecialTransaction(req.Txs[0])
+		if err != nil {
+			// ProcessProposal rejects such blocks, only possible if a
+			// supermajority accepted one anyway
+			s.logger.Error(fmt.Sprintf("❌️ :: No special Tx in finalized block %v :: %v", req.Height, err))
+			return &sdk.ResponsePreBlock{}, nil
+		}
+
+		allowLegacy := LegacyVoteExtensionsAllowed(ctx, s.paramSpace, int64(st.Height))
+		tally, err := TallyVotes(ctx, s.txDecoder, nil, s.cdc, st.ExtendedCommitInfo, allowLegacy)
+		if err != nil {
+			// ProcessProposal rejects such blocks too
+			s.logger.Error(fmt.Sprintf("❌️ :: Unable to tally special Tx in finalized block %v :: %v", req.Height, err))
+			return &sdk.ResponsePreBlock{}, nil
+		}
+
+		record := NewSpecialTxRecord(int64(st.Height), tally, BidThreshold(ctx, s.paramSpace, s.bidThreshold))
+		bz, err := record.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.storeService.OpenKVStore(ctx).Set(LastSpecialTxKey, bz); err != nil {
+			return nil, err
+		}
+
+		if s.promoter != nil {
+			if promoted := s.promoter.PromoteTally(ctx, tally); promoted > 0 {
+				s.logger.Info(fmt.Sprintf("🛠️ :: Promoted %v transactions from finalized block %v", promoted, req.Height))
+			}
+		}
+
+		return &sdk.ResponsePreBlock{}, nil
+	}
+}
+
+// NewSpecialTxRecord returns the record kept of a special transaction whose
+// vote extensions from height gave tally, holding the bids that crossed
+// threshold
+func NewSpecialTxRecord(height int64, tally VoteTally, threshold math.LegacyDec) abciv1.SpecialTxRecord {
+	crossed := CrossedBids(tally, threshold)
+	keys := make([]string, 0, len(crossed))
+	for key := range crossed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	record := abciv1.SpecialTxRecord{
+		Height:     height,
+		TotalPower: tally.TotalPower,
+		Bids:       make([]abciv1.TalliedBid, 0, len(keys)),
+	}
+	for _, key := range keys {
+		record.Bids = append(record.Bids, abciv1.TalliedBid{Key: key, Power: tally.BidPower[key]})
+	}
+	return record
+}
+
+// GetLastSpecialTxRecord returns the record of the last block that carried a
+// special transaction.
+func (s *SpecialTxStore) GetLastSpecialTxRecord(ctx context.Context) (abciv1.SpecialTxRecord, bool, error) {
+	bz, err := s.storeService.OpenKVStore(ctx).Get(LastSpecialTxKey)
+	if err != nil || bz == nil {
+		return abciv1.SpecialTxRecord{}, false, err
+	}
+
+	var record abciv1.SpecialTxRecord
+	if err := record.Unmarshal(bz); err != nil {
+		return abciv1.SpecialTxRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+var _ abciv1.QueryServer = &SpecialTxStore{}
+
+func (s *SpecialTxStore) LastSpecialTransaction(ctx context.Context, _ *abciv1.QueryLastSpecialTransactionRequest) (*abciv1.QueryLastSpecialTransactionResponse, error) {
+	record, found, err := s.GetLastSpecialTxRecord(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &abciv1.QueryLastSpecialTransactionResponse{Record: record, Found: found}, nil
+}
+
+// IsSpecialTransaction reports whether bz decodes as a special transaction.
+func IsSpecialTransaction(bz []byte) bool {
+	_, err := UnmarshalSpecialTransaction(bz)
+	return err == nil
+}
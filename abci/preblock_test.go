@@ -0,0 +1,87 @@
+package abci
+
+import (
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	storetypes "cosmossdk.io/store/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSpecialTxPreBlocker(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	key := storetypes.NewKVStoreKey(StoreKey)
+	ctx := testutil.DefaultContext(key, storetypes.NewTransientStoreKey("transient_test")).
+		WithConsensusParams(cmtproto.ConsensusParams{
+			Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 2},
+		})
+	s := NewSpecialTxStore(log.NewTestLogger(t), runtime.NewKVStoreService(key), txConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold, nil)
+	preBlocker := s.PreBlocker()
+	query := func() *abciv1.QueryLastSpecialTransactionResponse {
+		res, err := s.LastSpecialTransaction(ctx, &abciv1.QueryLastSpecialTransactionRequest{})
+		require.NoError(t, err)
+		return res
+	}
+
+	require.False(t, query().Found)
+
+	// Blocks without a special tx leave the store untouched
+	_, err := preBlocker(ctx, &abci.RequestFinalizeBlock{Height: 3, Txs: [][]byte{[]byte("garbage")}})
+	require.NoError(t, err)
+	require.False(t, query().Found)
+	require.False(t, IsSpecialTransaction([]byte("garbage")))
+
+	// Validators holding 70 of 100 power report a bid, one holding 30 another
+	crossed := newBid("bob.cosmos", testBidOwner)
+	_, crossedBz := buildTx(t, txConfig, nil, []sdk.Msg{crossed})
+	_, shortBz := buildTx(t, txConfig, nil, []sdk.Msg{newBid("alice.cosmos", testBidOwner)})
+	st := SpecialTransaction{
+		Height: 3,
+		ExtendedCommitInfo: abci.ExtendedCommitInfo{
+			Votes: []abci.ExtendedVoteInfo{newVote(t, 3, 40, crossedBz), newVote(t, 3, 30, crossedBz), newVote(t, 3, 30, shortBz)},
+		},
+	}
+	bz, err := st.Marshal()
+	require.NoError(t, err)
+	require.True(t, IsSpecialTransaction(bz))
+
+	// Only the tally is kept, with the bids that crossed the threshold
+	_, err = preBlocker(ctx, &abci.RequestFinalizeBlock{Height: 4, Txs: [][]byte{bz}})
+	require.NoError(t, err)
+	bidKey, err := Hash(crossed)
+	require.NoError(t, err)
+	res := query()
+	require.True(t, res.Found)
+	require.Equal(t, abciv1.SpecialTxRecord{
+		Height:     3,
+		TotalPower: 100,
+		Bids:       []abciv1.TalliedBid{{Key: bidKey, Power: 70}},
+	}, res.Record)
+}
+
+func TestNewSpecialTxRecord(t *testing.T) {
+	tally := VoteTally{
+		TotalPower: 100,
+		BidPower:   map[string]int64{"b": 70, "a": 60, "c": 40},
+	}
+
+	// Crossed bids are ordered by key
+	record := NewSpecialTxRecord(5, tally, DefaultBidThreshold)
+	require.Equal(t, abciv1.SpecialTxRecord{
+		Height:     5,
+		TotalPower: 100,
+		Bids:       []abciv1.TalliedBid{{Key: "a", Power: 60}, {Key: "b", Power: 70}},
+	}, record)
+
+	record = NewSpecialTxRecord(5, tally, math.LegacyNewDecWithPrec(9, 1))
+	require.Empty(t, record.Bids)
+}
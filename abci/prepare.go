@@ -0,0 +1,292 @@
+package abci
+
+import (
+	"context"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// proposal is the state passed between the stages of PrepareProposal
+type proposal struct {
+	specialTx []byte
+	// requiredTxs carry the bids the threshold saw at H-1, taken from the
+	// vote extensions, see requiredTxs
+	requiredTxs []encodedTx
+	// crossed holds the bids that crossed the threshold at H-1, the only bids
+	// a proposal may carry once vote extensions are enabled
+	crossed    map[string]bool
+	auctionTxs []sdk.Tx
+	defaultTxs []sdk.Tx
+	// auctionLane and defaultLane are the encoded lanes
+	auctionLane []encodedTx
+	defaultLane []encodedTx
+	// txs is the proposal handed back to CometBFT
+	txs [][]byte
+}
+
+type encodedTx struct {
+	tx  sdk.Tx
+	bz  []byte
+	gas uint64
+	// bids holds the hashes of the bids the tx carries
+	bids []string
+}
+
+type proposalStage struct {
+	name  string
+	build func(ctx sdk.Context, req *abci.RequestPrepareProposal, p proposal) (proposal, error)
+}
+
+// stages returns the PrepareProposal stages in the order they run. A stage
+// returning an error leaves the proposal as the previous stage built it.
+func (h *PrepareProposalHandler) stages() []proposalStage {
+	return []proposalStage{
+		{"special tx", h.buildSpecialTx},
+		{"lane selection", h.selectLanes},
+		{"provider", h.runTxProvider},
+		{"encoding", h.encodeLanes},
+		{"size checks", h.fillBlock},
+	}
+}
+
+// buildSpecialTx promotes the bids seen in the last commit's vote extensions
+// and builds the special tx from them, once vote extensions are enabled.
+func (h *PrepareProposalHandler) buildSpecialTx(ctx sdk.Context, req *abci.RequestPrepareProposal, p proposal) (proposal, error) {
+	if !SpecialTxExpected(ctx, req.Height) {
+		return p, nil
+	}
+
+	// Only build the Special Transaction from a correctly signed extended commit
+	err := baseapp.ValidateVoteExtensions(ctx, h.valStore, req.Height, ctx.ChainID(), req.LocalLastCommit)
+	if err != nil {
+		return p, fmt.Errorf("invalid vote extensions: %w", err)
+	}
+
+	// Promote bids the network saw, even if this node missed them in ExtendVote
+	promoted, err := h.promoter.Promote(ctx, req.LocalLastCommit)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("❌️ :: Unable to promote bids from Vote Extensions: %v", err))
+	} else {
+		h.logger.Info(fmt.Sprintf("🛠️ :: Promoted %v transactions from Vote Extensions", promoted))
+	}
+
+	// Embed the signed extended commit so validators can re-derive the bids themselves
+	st := SpecialTransaction{
+		Height:             int(req.Height - 1),
+		ExtendedCommitInfo: req.LocalLastCommit,
+	}
+	bz, err := st.Marshal()
+	if err != nil {
+		return p, fmt.Errorf("unable to marshal special tx: %w", err)
+	}
+	if len(bz) == 0 {
+		return p, fmt.Errorf("empty special tx")
+	}
+
+	// Bids the threshold saw must be included. Build them from the txs in the
+	// vote extensions, this node may never have received them.
+	allowLegacy := LegacyVoteExtensionsAllowed(ctx, h.paramSpace, req.Height-1)
+	tally, err := TallyVotes(ctx, h.txConfig.TxDecoder(), h.txVerifier, h.cdc, req.LocalLastCommit, allowLegacy)
+	if err != nil {
+		return p, fmt.Errorf("unable to tally vote extensions: %w", err)
+	}
+	threshold := BidThreshold(ctx, h.paramSpace, h.bidThreshold)
+	required, _ := requiredTxs(h.txConfig.TxDecoder(), tally, threshold)
+
+	p.specialTx = bz
+	p.requiredTxs = required
+	p.crossed = CrossedBids(tally, threshold)
+	return p, nil
+}
+
+// selectLanes takes the ready txs of both mempool lanes. Once there is a
+// special tx, txs carrying a bid that did not cross the threshold at H-1 are
+// left out, ProcessProposal would reject them.
+func (h *PrepareProposalHandler) selectLanes(_ sdk.Context, _ *abci.RequestPrepareProposal, p proposal) (proposal, error) {
+	p.auctionTxs, p.defaultTxs = nil, nil
+	for itr := h.mempool.Select(context.Background(), nil); itr != nil; itr = itr.Next() {
+		if p.proposable(itr.Tx()) {
+			p.auctionTxs = append(p.auctionTxs, itr.Tx())
+		}
+	}
+	for itr := h.mempool.SelectDefault(context.Background(), nil); itr != nil; itr = itr.Next() {
+		if p.proposable(itr.Tx()) {
+			p.defaultTxs = append(p.defaultTxs, itr.Tx())
+		}
+	}
+	h.logger.Info(fmt.Sprintf("🛠️ :: Number of Transactions available from mempool: %v", len(p.auctionTxs)+len(p.defaultTxs)))
+
+	return p, nil
+}
+
+// runTxProvider lets the provider rebuild the auction lane. Its txs replace
+// the lane only if every one of them encodes.
+func (h *PrepareProposalHandler) runTxProvider(ctx sdk.Context, _ *abci.RequestPrepareProposal, p proposal) (proposal, error) {
+	if !h.runProvider {
+		return p, nil
+	}
+
+	txs, err := h.txProvider.BuildProposal(ctx, p.auctionTxs)
+	if err != nil {
+		return p, err
+	}
+	for _, tx := range txs {
+		if _, err := h.encode(tx); err != nil {
+			return p, fmt.Errorf("provider tx: %w", err)
+		}
+	}
+
+	p.auctionTxs = txs
+	return p, nil
+}
+
+// encodeLanes encodes both lanes, dropping txs that fail to encode
+func (h *PrepareProposalHandler) encodeLanes(_ sdk.Context, _ *abci.RequestPrepareProposal, p proposal) (proposal, error) {
+	p.auctionLane = h.encodeLane(p.auctionTxs)
+	p.defaultLane = h.encodeLane(p.defaultTxs)
+	return p, nil
+}
+
+func (h *PrepareProposalHandler) encodeLane(txs []sdk.Tx) []encodedTx {
+	var lane []encodedTx
+	for _, tx := range txs {
+		bz, err := h.encode(tx)
+		if err != nil {
+			h.logger.Info(fmt.Sprintf("❌~Error encoding transaction: %v", err.Error()))
+			continue
+		}
+		lane = append(lane, encodedTx{tx: tx, bz: bz, gas: txGas(tx), bids: bidHashes(tx)})
+	}
+	return lane
+}
+
+// proposable reports whether tx may go in the proposal next to the special tx
+func (p proposal) proposable(tx sdk.Tx) bool {
+	if p.crossed == nil {
+		return true
+	}
+	for _, key := range bidHashes(tx) {
+		if !p.crossed[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// bidHashes returns the hashes of the bids tx carries
+func bidHashes(tx sdk.Tx) []string {
+	var keys []string
+	for _, msg := range tx.GetMsgs() {
+		bid, ok := msg.(*nstypes.MsgBid)
+		if !ok {
+			continue
+		}
+		if key, err := Hash(bid); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// txGas returns the gas limit of tx, or 0 if it sets none
+func txGas(tx sdk.Tx) uint64 {
+	if gasTx, ok := tx.(sdk.FeeTx); ok {
+		return gasTx.GetGas()
+	}
+	return 0
+}
+
+func (h *PrepareProposalHandler) encode(tx sdk.Tx) ([]byte, error) {
+	bz, err := h.txConfig.TxEncoder()(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(bz) == 0 {
+		return nil, fmt.Errorf("tx encoded to no bytes")
+	}
+	return bz, nil
+}
+
+// fillBlock assembles the proposal within the block's byte and gas limits.
+// The special tx and the txs carrying required bids take their space first,
+// then the auction lane takes up to its share of what is left and the default
+// lane fills the rest. Txs that do not fit are skipped. The special tx leads
+// the block, the other txs follow in the order they were added except that
+// each signer's txs are put in sequence order: a required tx may follow a
+// lane tx of the same signer.
+//
+// Once a special tx is expected, a proposal without one is rejected. If it is
+// missing or does not fit, the proposal is left empty instead, which validators
+// only accept when no special tx for the last commit fits the block.
+func (h *PrepareProposalHandler) fillBlock(ctx sdk.Context, req *abci.RequestPrepareProposal, p proposal) (proposal, error) {
+	limits := ProposalLimits(ctx.ConsensusParams(), len(req.LocalLastCommit.Votes))
+	space := &blockSpace{maxGas: limits.MaxGas}
+
+	var txs [][]byte
+	if SpecialTxExpected(ctx, req.Height) {
+		if p.specialTx == nil {
+			h.logger.Error("❌️ :: No Special Transaction, proposing an empty block")
+			p.txs = nil
+			return p, nil
+		}
+		if !space.add(p.specialTx, 0, req.MaxTxBytes) {
+			h.logger.Error(fmt.Sprintf("❌️ :: Special Transaction of %v bytes exceeds max tx bytes %v, proposing an empty block", len(p.specialTx), req.MaxTxBytes))
+			p.txs = nil
+			return p, nil
+		}
+		txs = append(txs, p.specialTx)
+	}
+
+	// Required txs stay within the limits validators check inclusion against,
+	// so any that are left out would not have fit in their view either
+	requiredLimit := req.MaxTxBytes
+	if limits.MaxTxBytes > 0 && limits.MaxTxBytes < requiredLimit {
+		requiredLimit = limits.MaxTxBytes
+	}
+	block := space.fill(p.requiredTxs, requiredLimit)
+	required := make(map[string]bool)
+	for _, tx := range p.requiredTxs {
+		for _, key := range tx.bids {
+			required[key] = true
+		}
+	}
+
+	auctionLimit := space.bytes + h.auctionShare.MulInt64(req.MaxTxBytes-space.bytes).TruncateInt64()
+	block = append(block, space.fill(exclude(p.auctionLane, required), auctionLimit)...)
+	block = append(block, space.fill(exclude(p.defaultLane, required), req.MaxTxBytes)...)
+
+	sdkTxs := make([]sdk.Tx, len(block))
+	for i, tx := range block {
+		sdkTxs[i] = tx.tx
+	}
+	for _, i := range mempool.SequenceOrder(sdkTxs) {
+		txs = append(txs, block[i].bz)
+	}
+
+	p.txs = txs
+	return p, nil
+}
+
+// exclude returns the txs of lane carrying none of bids. Another tx with a
+// required bid, e.g. an earlier version before a fee bump, would place the
+// bid twice.
+func exclude(lane []encodedTx, bids map[string]bool) []encodedTx {
+	var kept []encodedTx
+	for _, tx := range lane {
+		carries := false
+		for _, key := range tx.bids {
+			if bids[key] {
+				carries = true
+				break
+			}
+		}
+		if !carries {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
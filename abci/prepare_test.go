@@ -0,0 +1,386 @@
+package abci
+
+import (
+	"bytes"
+	"context"
+	"cosmossdk.io/log"
+	"errors"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cryptoenc "github.com/cometbft/cometbft/crypto/encoding"
+	cmtprotocrypto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	protoio "github.com/cosmos/gogoproto/io"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	"github.com/fatal-fruit/cosmapp/provider"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// stubProvider returns fixed txs from BuildProposal
+type stubProvider struct {
+	provider.TxProvider
+	txs []sdk.Tx
+	err error
+}
+
+func (p stubProvider) BuildProposal(_ sdk.Context, _ []sdk.Tx) ([]sdk.Tx, error) {
+	return p.txs, p.err
+}
+
+func TestPrepareProposalFallback(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+
+	send, sendBz := newSendTx(t, txConfig)
+	require.NoError(t, mp.Insert(context.Background(), send))
+	require.NoError(t, mp.Update(context.Background(), send))
+
+	prepare := func(pv provider.TxProvider, ctx sdk.Context, req *abci.RequestPrepareProposal) [][]byte {
+		h := NewPrepareProposalHandler(logger, txConfig, encCfg.Marshaler, mp, pv, pv != nil, nil, nil, paramstypes.Subspace{}, DefaultBidThreshold, nil, DefaultAuctionLaneShare)
+		res, err := h.PrepareProposalHandler()(ctx, req)
+		require.NoError(t, err)
+		for _, tx := range res.Txs {
+			require.NotEmpty(t, tx)
+		}
+		return res.Txs
+	}
+	req := &abci.RequestPrepareProposal{Height: 3, MaxTxBytes: 1 << 20}
+
+	// A failing provider falls back to the mempool's own selection
+	require.Equal(t, [][]byte{sendBz}, prepare(stubProvider{err: errors.New("no bids")}, sdk.Context{}, req))
+	// So does a provider returning a tx that cannot be encoded
+	require.Equal(t, [][]byte{sendBz}, prepare(stubProvider{txs: []sdk.Tx{send.(signedTx).Tx}}, sdk.Context{}, req))
+	require.Empty(t, prepare(stubProvider{}, sdk.Context{}, req))
+
+	// Unverifiable vote extensions leave no special tx, any txs without one
+	// would be rejected so the proposal is left empty
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	badCommit := &abci.RequestPrepareProposal{
+		Height:     3,
+		MaxTxBytes: 1 << 20,
+		LocalLastCommit: abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{{
+			Validator:   abci.Validator{Power: 10},
+			BlockIdFlag: cmtproto.BlockIDFlagCommit,
+		}}},
+	}
+	require.Empty(t, prepare(nil, ctx, badCommit))
+}
+
+// testValStore serves the consensus keys of a fixed validator set
+type testValStore map[string]cmtprotocrypto.PublicKey
+
+func (s testValStore) GetPubKeyByConsAddr(_ context.Context, addr sdk.ConsAddress) (cmtprotocrypto.PublicKey, error) {
+	pk, ok := s[string(addr)]
+	if !ok {
+		return cmtprotocrypto.PublicKey{}, fmt.Errorf("unknown validator %X", addr)
+	}
+	return pk, nil
+}
+
+// signVoteExt signs veBz as priv's vote extension at height 2 on chainID
+func signVoteExt(t *testing.T, priv ed25519.PrivKey, veBz []byte, chainID string) []byte {
+	var signBytes bytes.Buffer
+	require.NoError(t, protoio.NewDelimitedWriter(&signBytes).WriteMsg(&cmtproto.CanonicalVoteExtension{
+		Extension: veBz,
+		Height:    2,
+		ChainId:   chainID,
+	}))
+	sig, err := priv.Sign(signBytes.Bytes())
+	require.NoError(t, err)
+	return sig
+}
+
+// newSignedCommit returns the extended commit at height 2 of validators with
+// powers, the first reporting of which report txs in their signed vote
+// extensions. It also returns the matching last commit, the validators' keys
+// and a store serving them.
+func newSignedCommit(t *testing.T, chainID string, powers []int64, reporting int, txs ...[]byte) (abci.ExtendedCommitInfo, abci.CommitInfo, []ed25519.PrivKey, testValStore) {
+	valStore := testValStore{}
+	var privs []ed25519.PrivKey
+	var extCommit abci.ExtendedCommitInfo
+	var lastCommit abci.CommitInfo
+	for i, power := range powers {
+		priv := ed25519.GenPrivKey()
+		pk, err := cryptoenc.PubKeyToProto(priv.PubKey())
+		require.NoError(t, err)
+		valStore[string(priv.PubKey().Address())] = pk
+		privs = append(privs, priv)
+
+		ve := AppVoteExtension{Height: 2}
+		if i < reporting {
+			ve.Txs = txs
+		}
+		veBz, err := ve.Marshal()
+		require.NoError(t, err)
+
+		validator := abci.Validator{Address: priv.PubKey().Address(), Power: power}
+		extCommit.Votes = append(extCommit.Votes, abci.ExtendedVoteInfo{
+			Validator:          validator,
+			VoteExtension:      veBz,
+			ExtensionSignature: signVoteExt(t, priv, veBz, chainID),
+			BlockIdFlag:        cmtproto.BlockIDFlagCommit,
+		})
+		lastCommit.Votes = append(lastCommit.Votes, abci.VoteInfo{
+			Validator:   validator,
+			BlockIdFlag: cmtproto.BlockIDFlagCommit,
+		})
+	}
+	return extCommit, lastCommit, privs, valStore
+}
+
+func TestPrepareProposalAcceptedByProcessProposal(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder(), mempool.WithLanes(IsBidTx))
+
+	// The network saw a bid this node never received, and the node holds an
+	// earlier tx for the same bid, a bid promoted at an earlier height and a
+	// regular tx
+	bob := secp256k1.GenPrivKey().PubKey()
+	_, requiredBz := newBidTx(t, txConfig, bob, "bob.cosmos", withGas(100), withFee(2))
+	earlier, earlierBz := newBidTx(t, txConfig, bob, "bob.cosmos", withGas(100), withFee(1))
+	stale, staleBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "alice.cosmos", withGas(100), withFee(1))
+	send, sendBz := newSendTx(t, txConfig, withGas(100), withFee(1))
+	for _, tx := range []sdk.Tx{earlier, stale} {
+		require.NoError(t, mp.Insert(context.Background(), tx))
+		require.NoError(t, mp.Update(context.Background(), tx))
+	}
+	require.NoError(t, mp.Insert(context.Background(), send))
+
+	// Validators holding 70 of 100 power report the bid at H-1
+	const chainID = "test-chain"
+	extCommit, lastCommit, privs, valStore := newSignedCommit(t, chainID, []int64{40, 30, 30}, 2, requiredBz)
+
+	promoter := NewBidPromoter(logger, mp, txConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold)
+	prepare := NewPrepareProposalHandler(logger, txConfig, encCfg.Marshaler, mp, nil, false, valStore, nil, paramstypes.Subspace{}, DefaultBidThreshold, promoter, DefaultAuctionLaneShare)
+	process := NewProcessProposalHandler(logger, txConfig, encCfg.Marshaler, valStore, nil, paramstypes.Subspace{}, DefaultBidThreshold)
+
+	roundTrip := func(maxGas, maxTxBytes int64) [][]byte {
+		ctx := sdk.Context{}.WithChainID(chainID).WithConsensusParams(cmtproto.ConsensusParams{
+			Block:    &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: maxGas},
+			Evidence: &cmtproto.EvidenceParams{MaxBytes: 1 << 10},
+			Abci:     &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+		})
+		res, err := prepare.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{
+			Height:          3,
+			MaxTxBytes:      maxTxBytes,
+			LocalLastCommit: extCommit,
+		})
+		require.NoError(t, err)
+
+		// The same node accepts what it proposed
+		resp, err := process.ProcessProposalHandler()(ctx, &abci.RequestProcessProposal{
+			Height:             3,
+			Txs:                res.Txs,
+			ProposedLastCommit: lastCommit,
+		})
+		require.NoError(t, err)
+		require.Equal(t, abci.ResponseProcessProposal_ACCEPT, resp.Status)
+		return res.Txs
+	}
+
+	// The required bid goes in as the network reported it, once, and the bid
+	// without evidence at H-1 is left out
+	txs := roundTrip(-1, 1<<20)
+	require.Len(t, txs, 3)
+	require.Equal(t, requiredBz, txs[1])
+	require.Equal(t, sendBz, txs[2])
+	require.NotContains(t, txs, earlierBz)
+	require.NotContains(t, txs, staleBz)
+
+	// A block the special tx fills leaves no room for the required bid, its
+	// omission is excused rather than the room left taken as unbounded
+	special := txs[0]
+	cp := cmtproto.ConsensusParams{
+		Block:    &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: -1},
+		Evidence: &cmtproto.EvidenceParams{MaxBytes: 1 << 10},
+		Abci:     &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	}
+	cp.Block.MaxBytes -= ProposalLimits(cp, len(lastCommit.Votes)).MaxTxBytes - txSize(special)
+	for _, maxBytes := range []int64{cp.Block.MaxBytes, cp.Block.MaxBytes - 10} {
+		cp.Block.MaxBytes = maxBytes
+		resp, err := process.ProcessProposalHandler()(sdk.Context{}.WithChainID(chainID).WithConsensusParams(cp), &abci.RequestProcessProposal{
+			Height:             3,
+			Txs:                [][]byte{special},
+			ProposedLastCommit: lastCommit,
+		})
+		require.NoError(t, err)
+		require.Equal(t, abci.ResponseProcessProposal_ACCEPT, resp.Status)
+	}
+
+	// Without gas for it the required bid is omitted, and that is accepted
+	txs = roundTrip(50, 1<<20)
+	require.Len(t, txs, 1)
+
+	// Filler cannot take the required bid's place: with gas for one of them, a
+	// block holding the regular tx instead of the bid censors it
+	fillerCtx := sdk.Context{}.WithChainID(chainID).WithConsensusParams(cmtproto.ConsensusParams{
+		Block:    &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: 150},
+		Evidence: &cmtproto.EvidenceParams{MaxBytes: 1 << 10},
+		Abci:     &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	for _, tc := range []struct {
+		txs    [][]byte
+		status abci.ResponseProcessProposal_ProposalStatus
+	}{
+		{[][]byte{special, sendBz}, abci.ResponseProcessProposal_REJECT},
+		{[][]byte{special, requiredBz}, abci.ResponseProcessProposal_ACCEPT},
+	} {
+		resp, err := process.ProcessProposalHandler()(fillerCtx, &abci.RequestProcessProposal{
+			Height:             3,
+			Txs:                tc.txs,
+			ProposedLastCommit: lastCommit,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.status, resp.Status)
+	}
+
+	// Without room for the special tx the block is proposed empty. That is
+	// rejected while the smallest special tx for the last commit fits, and
+	// accepted rather than halting the chain once it does not.
+	ctx := sdk.Context{}.WithChainID(chainID).WithConsensusParams(cp)
+	res, err := prepare.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{
+		Height:          3,
+		MaxTxBytes:      64,
+		LocalLastCommit: extCommit,
+	})
+	require.NoError(t, err)
+	require.Empty(t, res.Txs)
+
+	minTx, err := minSpecialTx(3, lastCommit)
+	require.NoError(t, err)
+	require.Less(t, txSize(minTx), txSize(special))
+	empty := cp
+	empty.Block = &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: -1}
+	empty.Block.MaxBytes -= ProposalLimits(empty, len(lastCommit.Votes)).MaxTxBytes - txSize(minTx)
+	for _, tc := range []struct {
+		maxBytes int64
+		status   abci.ResponseProcessProposal_ProposalStatus
+	}{
+		{empty.Block.MaxBytes, abci.ResponseProcessProposal_REJECT},
+		{empty.Block.MaxBytes - 1, abci.ResponseProcessProposal_ACCEPT},
+	} {
+		empty.Block.MaxBytes = tc.maxBytes
+		resp, err := process.ProcessProposalHandler()(sdk.Context{}.WithChainID(chainID).WithConsensusParams(empty), &abci.RequestProcessProposal{
+			Height:             3,
+			ProposedLastCommit: lastCommit,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.status, resp.Status)
+	}
+
+	// A vote extension signature that does not verify fails the whole commit:
+	// the proposer leaves its block empty, and a special tx carrying it is
+	// rejected
+	tamper := func(sig func([]byte) []byte) abci.ExtendedCommitInfo {
+		tampered := extCommit
+		tampered.Votes = append([]abci.ExtendedVoteInfo{}, extCommit.Votes...)
+		tampered.Votes[1].ExtensionSignature = sig(tampered.Votes[1].ExtensionSignature)
+		return tampered
+	}
+	tests := []struct {
+		name   string
+		commit abci.ExtendedCommitInfo
+	}{
+		{"forged signature", tamper(func(sig []byte) []byte {
+			forged := append([]byte{}, sig...)
+			forged[0] ^= 0xff
+			return forged
+		})},
+		{"missing signature", tamper(func([]byte) []byte { return nil })},
+		{"wrong chain", tamper(func([]byte) []byte {
+			return signVoteExt(t, privs[1], extCommit.Votes[1].VoteExtension, "other-chain")
+		})},
+	}
+	processCommit := func(ctx sdk.Context, commit abci.ExtendedCommitInfo) abci.ResponseProcessProposal_ProposalStatus {
+		special, err := SpecialTransaction{Height: 2, ExtendedCommitInfo: commit}.Marshal()
+		require.NoError(t, err)
+		resp, err := process.ProcessProposalHandler()(ctx, &abci.RequestProcessProposal{
+			Height:             3,
+			Txs:                [][]byte{special, requiredBz},
+			ProposedLastCommit: lastCommit,
+		})
+		require.NoError(t, err)
+		return resp.Status
+	}
+	cp.Block.MaxBytes = 1 << 20
+	require.Equal(t, abci.ResponseProcessProposal_ACCEPT, processCommit(sdk.Context{}.WithChainID(chainID).WithConsensusParams(cp), extCommit))
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := sdk.Context{}.WithChainID(chainID).WithConsensusParams(cp)
+			res, err := prepare.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{
+				Height:          3,
+				MaxTxBytes:      1 << 20,
+				LocalLastCommit: tc.commit,
+			})
+			require.NoError(t, err)
+			require.Empty(t, res.Txs)
+
+			require.Equal(t, abci.ResponseProcessProposal_REJECT, processCommit(ctx, tc.commit))
+		})
+	}
+}
+
+func TestPrepareProposalOrdersRequiredTxsBySequence(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder(), mempool.WithLanes(IsBidTx))
+
+	// Alice's two bids crossed the threshold, the one required first signed
+	// for her later sequence. Her earlier send is in the default lane.
+	alice := secp256k1.GenPrivKey().PubKey()
+	names := []string{"alice.cosmos", "alicia.cosmos"}
+	owner := sdk.AccAddress(alice.Address()).String()
+	first, err := Hash(newBid(names[0], owner))
+	require.NoError(t, err)
+	second, err := Hash(newBid(names[1], owner))
+	require.NoError(t, err)
+	if second < first {
+		names[0], names[1] = names[1], names[0]
+	}
+	_, laterBz := newBidTx(t, txConfig, alice, names[0], withSequence(2))
+	_, earlierBz := newBidTx(t, txConfig, alice, names[1], withSequence(1))
+	send, sendBz := newSendTxFrom(t, txConfig, alice, withSequence(0))
+	require.NoError(t, mp.Insert(context.Background(), send))
+
+	const chainID = "test-chain"
+	extCommit, lastCommit, _, valStore := newSignedCommit(t, chainID, []int64{40, 30, 30}, 2, laterBz, earlierBz)
+	promoter := NewBidPromoter(logger, mp, txConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold)
+	prepare := NewPrepareProposalHandler(logger, txConfig, encCfg.Marshaler, mp, nil, false, valStore, nil, paramstypes.Subspace{}, DefaultBidThreshold, promoter, DefaultAuctionLaneShare)
+	process := NewProcessProposalHandler(logger, txConfig, encCfg.Marshaler, valStore, nil, paramstypes.Subspace{}, DefaultBidThreshold)
+
+	ctx := sdk.Context{}.WithChainID(chainID).WithConsensusParams(cmtproto.ConsensusParams{
+		Block:    &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: -1},
+		Evidence: &cmtproto.EvidenceParams{MaxBytes: 1 << 10},
+		Abci:     &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	res, err := prepare.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{
+		Height:          3,
+		MaxTxBytes:      1 << 20,
+		LocalLastCommit: extCommit,
+	})
+	require.NoError(t, err)
+
+	// Her txs follow the special tx in sequence order, and validators accept it
+	require.Len(t, res.Txs, 4)
+	require.Equal(t, [][]byte{sendBz, earlierBz, laterBz}, res.Txs[1:])
+	resp, err := process.ProcessProposalHandler()(ctx, &abci.RequestProcessProposal{
+		Height:             3,
+		Txs:                res.Txs,
+		ProposedLastCommit: lastCommit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseProcessProposal_ACCEPT, resp.Status)
+}
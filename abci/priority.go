@@ -0,0 +1,57 @@
+package abci
+
+import (
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"math"
+)
+
+const (
+	// PriorityFee orders the ready pool by gas price
+	PriorityFee = "fee"
+	// PriorityBid orders bid txs by the amount bid
+	PriorityBid = "bid"
+)
+
+// MempoolPriority returns the mempool priority function configured by name
+func MempoolPriority(name string, denom string) (mempool.PriorityFunc, error) {
+	switch name {
+	case "", PriorityFee:
+		return mempool.FeePerGasPriority, nil
+	case PriorityBid:
+		return BidPriority(denom), nil
+	default:
+		return nil, fmt.Errorf("unknown mempool priority %q, expected %q or %q", name, PriorityFee, PriorityBid)
+	}
+}
+
+// BidPriority prioritises txs carrying a MsgBid by the largest amount of
+// denom they bid. Txs without bids fall back to their gas price.
+func BidPriority(denom string) mempool.PriorityFunc {
+	return func(tx sdk.Tx) int64 {
+		var priority int64
+		hasBid := false
+		for _, msg := range tx.GetMsgs() {
+			bid, ok := msg.(*nstypes.MsgBid)
+			if !ok {
+				continue
+			}
+			hasBid = true
+
+			p := int64(math.MaxInt64)
+			if amt := bid.Amount.AmountOf(denom); amt.IsInt64() {
+				p = amt.Int64()
+			}
+			if p > priority {
+				priority = p
+			}
+		}
+
+		if !hasBid {
+			return mempool.FeePerGasPriority(tx)
+		}
+		return priority
+	}
+}
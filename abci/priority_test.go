@@ -0,0 +1,38 @@
+package abci
+
+import (
+	"cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBidPriority(t *testing.T) {
+	txConfig := newSignedTxConfig(testutils.MakeTestEncodingConfig().TxConfig)
+
+	bidTx := func(amounts ...int64) sdk.Tx {
+		var msgs []sdk.Msg
+		for _, amt := range amounts {
+			bid := newBid("bob.cosmos", testBidOwner)
+			bid.Amount = sdk.Coins{sdk.NewCoin("uatom", math.NewInt(amt))}
+			msgs = append(msgs, bid)
+		}
+		tx, _ := buildTx(t, txConfig, nil, msgs, withGas(10), withFee(70))
+		return tx
+	}
+
+	priority, err := MempoolPriority(PriorityBid, "uatom")
+	require.NoError(t, err)
+	require.Equal(t, int64(500), priority(bidTx(500)))
+	require.Equal(t, int64(900), priority(bidTx(500, 900)))
+	// Txs without bids are ordered by gas price
+	require.Equal(t, int64(7), priority(bidTx()))
+
+	priority, err = MempoolPriority(PriorityFee, "uatom")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), priority(bidTx(500)))
+
+	_, err = MempoolPriority("random", "uatom")
+	require.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"sync"
+)
+
+// BidPromoter moves pending txs to the ready pool once the network's vote
+// extensions show their bids were seen by the bid threshold. Every node
+// applies the same committed extensions, so ready pools agree across nodes
+// whether or not the node ran ExtendVote itself.
+type BidPromoter struct {
+	logger     log.Logger
+	mempool    *mempool.ThresholdMempool
+	txDecoder  sdk.TxDecoder
+	cdc        codec.Codec
+	paramSpace paramstypes.Subspace
+	// bidThreshold is the node's default, the on-chain param overrides it
+	bidThreshold math.LegacyDec
+
+	mtx sync.RWMutex
+	// seen holds the bids in the last extended commit promoted from
+	seen map[string]bool
+}
+
+func NewBidPromoter(lg log.Logger, mp *mempool.ThresholdMempool, txDecoder sdk.TxDecoder, cdc codec.Codec, ps paramstypes.Subspace, bidThreshold math.LegacyDec) *BidPromoter {
+	return &BidPromoter{
+		logger:       lg,
+		mempool:      mp,
+		txDecoder:    txDecoder,
+		cdc:          cdc,
+		paramSpace:   ps,
+		bidThreshold: bidThreshold,
+	}
+}
+
+// Promote tallies the vote extensions in extCommit and promotes every pending
+// tx whose bids all crossed the threshold, returning the number promoted.
+// Pending txs carrying no bids, only held there when the mempool has no
+// lanes, need no evidence and are promoted by any extended commit.
+func (p *BidPromoter) Promote(ctx sdk.Context, extCommit abci.ExtendedCommitInfo) (int, error) {
+	allowLegacy := LegacyVoteExtensionsAllowed(ctx, p.paramSpace, ctx.BlockHeight()-1)
+	tally, err := TallyVotes(ctx, p.txDecoder, nil, p.cdc, extCommit, allowLegacy)
+	if err != nil {
+		return 0, err
+	}
+	return p.PromoteTally(ctx, tally), nil
+}
+
+// PromoteTally promotes like Promote, from vote extensions already tallied
+func (p *BidPromoter) PromoteTally(ctx sdk.Context, tally VoteTally) int {
+	seen := make(map[string]bool, len(tally.BidPower))
+	for key := range tally.BidPower {
+		seen[key] = true
+	}
+	p.mtx.Lock()
+	p.seen = seen
+	p.mtx.Unlock()
+
+	crossed := CrossedBids(tally, BidThreshold(ctx, p.paramSpace, p.bidThreshold))
+
+	promoted := 0
+	for itr := p.mempool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+		tx := itr.Tx()
+		// A tx with any bid short of the threshold would fail ValidateBids
+		if !bidsIn(tx, crossed) {
+			continue
+		}
+		if err := p.mempool.Update(context.Background(), tx); err != nil {
+			p.logger.Info(fmt.Sprintf("Unable to promote mempool tx: %v", err))
+			continue
+		}
+		promoted++
+	}
+
+	return promoted
+}
+
+// Observed reports whether a bid in tx was seen in the vote extensions last
+// passed to Promote, whether or not it crossed the threshold
+func (p *BidPromoter) Observed(tx sdk.Tx) bool {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return carriesBid(tx, p.seen)
+}
+
+// carriesBid reports whether tx carries a bid whose hash is in bids
+func carriesBid(tx sdk.Tx, bids map[string]bool) bool {
+	for _, msg := range tx.GetMsgs() {
+		bid, ok := msg.(*nstypes.MsgBid)
+		if !ok {
+			continue
+		}
+		if key, err := Hash(bid); err == nil && bids[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// bidsIn reports whether every bid tx carries is in bids, which holds for a
+// tx carrying none
+func bidsIn(tx sdk.Tx, bids map[string]bool) bool {
+	for _, msg := range tx.GetMsgs() {
+		bid, ok := msg.(*nstypes.MsgBid)
+		if !ok {
+			continue
+		}
+		if key, err := Hash(bid); err != nil || !bids[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBidTx reports whether tx carries a name bid. It routes txs into the
+// threshold lane of the mempool, other txs go to the default lane.
+func IsBidTx(tx sdk.Tx) bool {
+	for _, msg := range tx.GetMsgs() {
+		if _, ok := msg.(*nstypes.MsgBid); ok {
+			return true
+		}
+	}
+	return false
+}
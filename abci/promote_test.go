@@ -0,0 +1,81 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBidPromoter(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	promoter := NewBidPromoter(logger, mp, txConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold)
+
+	seen, seenBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "seen.cosmos")
+	unseen, unseenBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "unseen.cosmos")
+	require.NoError(t, mp.Insert(context.Background(), seen))
+	require.NoError(t, mp.Insert(context.Background(), unseen))
+
+	extCommit := abci.ExtendedCommitInfo{
+		Votes: []abci.ExtendedVoteInfo{
+			newVote(t, 1, 40, seenBz, unseenBz),
+			newVote(t, 1, 30, seenBz),
+			newVote(t, 1, 30),
+		},
+	}
+
+	promoted, err := promoter.Promote(sdk.Context{}, extCommit)
+	require.NoError(t, err)
+	require.Equal(t, 1, promoted)
+
+	itr := mp.Select(context.Background(), nil)
+	require.NotNil(t, itr)
+	require.Equal(t, "seen.cosmos", itr.Tx().GetMsgs()[0].(*nstypes.MsgBid).Name)
+	require.Nil(t, itr.Next())
+	require.Equal(t, 1, mp.CountTx())
+
+	// Bids below the threshold are still reported as observed
+	require.True(t, promoter.Observed(seen))
+	require.True(t, promoter.Observed(unseen))
+	other, _ := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "other.cosmos")
+	require.False(t, promoter.Observed(other))
+
+	// Promotion is idempotent when the same extensions are applied again
+	promoted, err = promoter.Promote(sdk.Context{}, extCommit)
+	require.NoError(t, err)
+	require.Equal(t, 0, promoted)
+}
+
+func TestBidPromoterWithoutLanes(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	promoter := NewBidPromoter(logger, mp, txConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold)
+
+	// Without lanes a bank send waits in the pending pool like a bid
+	send, _ := newSendTx(t, txConfig)
+	require.NoError(t, mp.Insert(context.Background(), send))
+	require.Nil(t, mp.Select(context.Background(), nil))
+
+	// It needs no evidence, an extended commit reporting no bids promotes it
+	extCommit := abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{newVote(t, 1, 10)}}
+	promoted, err := promoter.Promote(sdk.Context{}, extCommit)
+	require.NoError(t, err)
+	require.Equal(t, 1, promoted)
+
+	itr := mp.Select(context.Background(), nil)
+	require.NotNil(t, itr)
+	require.Equal(t, send, itr.Tx())
+	require.Nil(t, mp.SelectPending(context.Background(), nil))
+}
@@ -1,20 +1,34 @@
 package abci
 
 import (
-	"context"
+	"bytes"
 	"cosmossdk.io/log"
+	"cosmossdk.io/math"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 	"github.com/fatal-fruit/cosmapp/mempool"
 	"github.com/fatal-fruit/cosmapp/provider"
 	nstypes "github.com/fatal-fruit/ns/types"
+	gomath "math"
+	"sort"
 )
 
+// DefaultBidThreshold is the fraction of voting power that must have observed a bid
+// when neither the node config nor the chain set one
+var DefaultBidThreshold = math.LegacyNewDecWithPrec(5, 1)
+
+// DefaultAuctionLaneShare is the fraction of block space reserved for the
+// auction lane, the default lane fills whatever it leaves
+var DefaultAuctionLaneShare = math.LegacyNewDecWithPrec(5, 1)
+
 func NewPrepareProposalHandler(
 	lg log.Logger,
 	txCg client.TxConfig,
@@ -22,70 +36,67 @@ func NewPrepareProposalHandler(
 	mp *mempool.ThresholdMempool,
 	pv provider.TxProvider,
 	runProv bool,
+	valStore baseapp.ValidatorStore,
+	verifier TxVerifier,
+	ps paramstypes.Subspace,
+	bidThreshold math.LegacyDec,
+	promoter *BidPromoter,
+	auctionShare math.LegacyDec,
 ) *PrepareProposalHandler {
 	return &PrepareProposalHandler{
-		logger:      lg,
-		txConfig:    txCg,
-		cdc:         cdc,
-		mempool:     mp,
-		txProvider:  pv,
-		runProvider: runProv,
+		logger:       lg,
+		txConfig:     txCg,
+		cdc:          cdc,
+		mempool:      mp,
+		txProvider:   pv,
+		runProvider:  runProv,
+		valStore:     valStore,
+		txVerifier:   verifier,
+		paramSpace:   ps,
+		bidThreshold: bidThreshold,
+		promoter:     promoter,
+		auctionShare: auctionShare,
 	}
 }
 
 func (h *PrepareProposalHandler) PrepareProposalHandler() sdk.PrepareProposalHandler {
 	return func(ctx sdk.Context, req *abci.RequestPrepareProposal) (*abci.ResponsePrepareProposal, error) {
 		h.logger.Info(fmt.Sprintf("🛠️ :: Prepare Proposal"))
-		var proposalTxs [][]byte
-
-		// Get Vote Extensions
-		if req.Height > 2 {
-
-			// Get Special Transaction
-			ve, err := processVoteExtensions(req, h.logger)
-			if err != nil {
-				h.logger.Error(fmt.Sprintf("❌️ :: Unable to process Vote Extensions: %v", err))
-			}
-
-			// Marshal Special Transaction
-			bz, err := json.Marshal(ve)
-			if err != nil {
-				h.logger.Error(fmt.Sprintf("❌️ :: Unable to marshal Vote Extensions: %v", err))
-			}
-
-			// Append Special Transaction to proposal
-			proposalTxs = append(proposalTxs, bz)
-		}
-
-		var txs []sdk.Tx
-		itr := h.mempool.Select(context.Background(), nil)
-		for itr != nil {
-			tmptx := itr.Tx()
 
-			txs = append(txs, tmptx)
-			itr = itr.Next()
-		}
-		h.logger.Info(fmt.Sprintf("🛠️ :: Number of Transactions available from mempool: %v", len(txs)))
-
-		if h.runProvider {
-			tmpMsgs, err := h.txProvider.BuildProposal(ctx, txs)
+		// Each stage builds on the last valid proposal, a failed stage is skipped
+		var p proposal
+		for _, stage := range h.stages() {
+			next, err := stage.build(ctx, req, p)
 			if err != nil {
-				h.logger.Error(fmt.Sprintf("❌️ :: Error Building Custom Proposal: %v", err))
+				h.logger.Error(fmt.Sprintf("❌️ :: Proposal stage %v failed, falling back: %v", stage.name, err))
+				continue
 			}
-			txs = tmpMsgs
+			p = next
 		}
 
-		for _, sdkTxs := range txs {
-			txBytes, err := h.txConfig.TxEncoder()(sdkTxs)
-			if err != nil {
-				h.logger.Info(fmt.Sprintf("❌~Error encoding transaction: %v", err.Error()))
-			}
-			proposalTxs = append(proposalTxs, txBytes)
-		}
+		h.logger.Info(fmt.Sprintf("🛠️ :: Number of Transactions in proposal: %v", len(p.txs)))
 
-		h.logger.Info(fmt.Sprintf("🛠️ :: Number of Transactions in proposal: %v", len(proposalTxs)))
+		return &abci.ResponsePrepareProposal{Txs: p.txs}, nil
+	}
+}
 
-		return &abci.ResponsePrepareProposal{Txs: proposalTxs}, nil
+func NewProcessProposalHandler(
+	lg log.Logger,
+	txCg client.TxConfig,
+	cdc codec.Codec,
+	valStore baseapp.ValidatorStore,
+	verifier TxVerifier,
+	ps paramstypes.Subspace,
+	bidThreshold math.LegacyDec,
+) *ProcessProposalHandler {
+	return &ProcessProposalHandler{
+		TxConfig:     txCg,
+		Codec:        cdc,
+		Logger:       lg,
+		ValStore:     valStore,
+		TxVerifier:   verifier,
+		ParamSpace:   ps,
+		BidThreshold: bidThreshold,
 	}
 }
 
@@ -93,40 +104,77 @@ func (h *ProcessProposalHandler) ProcessProposalHandler() sdk.ProcessProposalHan
 	return func(ctx sdk.Context, req *abci.RequestProcessProposal) (resp *abci.ResponseProcessProposal, err error) {
 		h.Logger.Info(fmt.Sprintf("⚙️ :: Process Proposal"))
 
+		// Before vote extensions were produced there is no Special Transaction to
+		// check, from then on every proposal with txs must carry a valid one
+		if !SpecialTxExpected(ctx, req.Height) {
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_ACCEPT}, nil
+		}
+
 		// The first transaction will always be the Special Transaction
 		numTxs := len(req.Txs)
-		if numTxs == 1 {
-			h.Logger.Info(fmt.Sprintf("⚙️:: Number of transactions :: %v", numTxs))
+		h.Logger.Info(fmt.Sprintf("⚙️:: Number of transactions :: %v", numTxs))
+		if numTxs == 0 {
+			// Proposed when the Special Transaction does not fit in the block. That
+			// is only accepted if even the smallest one for the last commit could
+			// not, rejecting it would halt the chain.
+			limits := ProposalLimits(ctx.ConsensusParams(), len(req.ProposedLastCommit.Votes))
+			minTx, err := minSpecialTx(req.Height, req.ProposedLastCommit)
+			if err != nil {
+				h.Logger.Error(fmt.Sprintf("❌️:: Error sizing special Tx in Process Proposal :: %v", err))
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+			if limits.MaxTxBytes <= 0 || txSize(minTx) <= limits.MaxTxBytes {
+				h.Logger.Error("❌️:: Empty proposal without special Tx, though one fits")
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+			}
+			h.Logger.Info("⚙️:: Accepting empty proposal without room for a special Tx")
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_ACCEPT}, nil
 		}
 
-		if numTxs >= 1 {
-			h.Logger.Info(fmt.Sprintf("⚙️:: Number of transactions :: %v", numTxs))
-			var st SpecialTransaction
-			err = json.Unmarshal(req.Txs[0], &st)
-			if err != nil {
-				h.Logger.Error(fmt.Sprintf("❌️:: Error unmarshalling special Tx in Process Proposal :: %v", err))
-			}
-			if len(st.Bids) > 0 {
-				h.Logger.Info(fmt.Sprintf("⚙️:: There are bids in the Special Transaction"))
-				var bids []nstypes.MsgBid
-				for i, b := range st.Bids {
-					var bid nstypes.MsgBid
-					h.Codec.Unmarshal(b, &bid)
-					h.Logger.Info(fmt.Sprintf("⚙️:: Special Transaction Bid No %v :: %v", i, bid))
-					bids = append(bids, bid)
-				}
-				// Validate Bids in Tx
-				txs := req.Txs[1:]
-				ok, err := ValidateBids(h.TxConfig, bids, txs, h.Logger)
-				if err != nil {
-					h.Logger.Error(fmt.Sprintf("❌️:: Error validating bids in Process Proposal :: %v", err))
-					return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
-				}
-				if !ok {
-					h.Logger.Error(fmt.Sprintf("❌️:: Unable to validate bids in Process Proposal :: %v", err))
-					return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
-				}
-				h.Logger.Info("⚙️:: Successfully validated bids in Process Proposal")
+		st, err := UnmarshalSpecialTransaction(req.Txs[0])
+		if err == nil && st.Height != int(req.Height-1) {
+			err = fmt.Errorf("special Tx height %d, expected %d", st.Height, req.Height-1)
+		}
+		if err != nil {
+			h.Logger.Error(fmt.Sprintf("❌️:: Error unmarshalling special Tx in Process Proposal :: %v", err))
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+
+		// Never trust the proposer, re-verify the extended commit and derive the bids from it
+		err = verifyExtendedCommit(ctx, h.ValStore, req.Height, st.ExtendedCommitInfo, req.ProposedLastCommit)
+		if err != nil {
+			h.Logger.Error(fmt.Sprintf("❌️:: Invalid extended commit in special Tx :: %v", err))
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+		allowLegacy := LegacyVoteExtensionsAllowed(ctx, h.ParamSpace, req.Height-1)
+		tally, err := TallyVotes(ctx, h.TxConfig.TxDecoder(), h.TxVerifier, h.Codec, st.ExtendedCommitInfo, allowLegacy)
+		if err != nil {
+			h.Logger.Error(fmt.Sprintf("❌️:: Error tallying vote extension bids in Process Proposal :: %v", err))
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+
+		// Every bid needs VE evidence, even when no bids were observed at H-1
+		h.Logger.Info(fmt.Sprintf("⚙️:: Validating bids against %v bids in the Special Transaction", len(tally.BidPower)))
+		threshold := BidThreshold(ctx, h.ParamSpace, h.BidThreshold)
+		ok, err := ValidateBids(h.TxConfig, tally, threshold, req.Txs[1:], h.Logger)
+		if err != nil {
+			h.Logger.Error(fmt.Sprintf("❌️:: Error validating bids in Process Proposal :: %v", err))
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+		if !ok {
+			h.Logger.Error(fmt.Sprintf("❌️:: Unable to validate bids in Process Proposal :: %v", err))
+			return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
+		}
+		h.Logger.Info("⚙️:: Successfully validated bids in Process Proposal")
+
+		// Bids seen by the threshold at H-1 must be included, a proposer may not censor them
+		if len(tally.BidPower) > 0 {
+			// The special tx takes its space first, as in PrepareProposal
+			limits := ProposalLimits(ctx.ConsensusParams(), len(req.ProposedLastCommit.Votes)).without(req.Txs[0])
+			ok, err := ValidateInclusion(h.TxConfig, tally, threshold, req.Txs[1:], limits, h.Logger)
+			if err != nil || !ok {
+				h.Logger.Error(fmt.Sprintf("❌️:: Proposal omits required bids :: %v", err))
+				return &abci.ResponseProcessProposal{Status: abci.ResponseProcessProposal_REJECT}, nil
 			}
 		}
 
@@ -134,75 +182,207 @@ func (h *ProcessProposalHandler) ProcessProposalHandler() sdk.ProcessProposalHan
 	}
 }
 
-func processVoteExtensions(req *abci.RequestPrepareProposal, log log.Logger) (SpecialTransaction, error) {
-	log.Info(fmt.Sprintf("🛠️ :: Process Vote Extensions"))
+// minSpecialTx returns the smallest special transaction a proposal at height
+// could carry for lastCommit: its votes without vote extensions or signatures.
+func minSpecialTx(height int64, lastCommit abci.CommitInfo) ([]byte, error) {
+	extCommit := abci.ExtendedCommitInfo{Round: lastCommit.Round}
+	for _, vote := range lastCommit.Votes {
+		extCommit.Votes = append(extCommit.Votes, abci.ExtendedVoteInfo{
+			Validator:   vote.Validator,
+			BlockIdFlag: vote.BlockIdFlag,
+		})
+	}
+	return SpecialTransaction{Height: int(height - 1), ExtendedCommitInfo: extCommit}.Marshal()
+}
 
-	// Create empty response
-	st := SpecialTransaction{
-		0,
-		[][]byte{},
+// verifyExtendedCommit checks the extended commit embedded in a special
+// transaction against the last commit CometBFT handed to ProcessProposal, so a
+// proposer cannot drop votes or alter voting power, and then verifies every
+// vote extension signature.
+func verifyExtendedCommit(ctx sdk.Context, valStore baseapp.ValidatorStore, height int64, extCommit abci.ExtendedCommitInfo, lastCommit abci.CommitInfo) error {
+	if extCommit.Round != lastCommit.Round {
+		return fmt.Errorf("extended commit round %d does not match last commit round %d", extCommit.Round, lastCommit.Round)
+	}
+	if len(extCommit.Votes) != len(lastCommit.Votes) {
+		return fmt.Errorf("extended commit has %d votes, last commit has %d", len(extCommit.Votes), len(lastCommit.Votes))
+	}
+	for i, vote := range extCommit.Votes {
+		lastVote := lastCommit.Votes[i]
+		if !bytes.Equal(vote.Validator.Address, lastVote.Validator.Address) ||
+			vote.Validator.Power != lastVote.Validator.Power ||
+			vote.BlockIdFlag != lastVote.BlockIdFlag {
+			return fmt.Errorf("extended commit vote %d does not match last commit", i)
+		}
 	}
 
-	// Get Vote Ext for H-1 from Req
-	voteExt := req.GetLocalLastCommit()
-	votes := voteExt.Votes
+	return baseapp.ValidateVoteExtensions(ctx, valStore, height, ctx.ChainID(), extCommit)
+}
+
+// TallyVotes sums the voting power that observed each bid in a verified
+// extended commit. A bid repeated in a single vote extension is only counted
+// once for that validator, and only committed votes count towards a bid.
+//
+// Validators may report different txs carrying the same bid, e.g. after a fee
+// bump, and a byzantine validator may report a tampered one. Each bid is
+// paired with the tx reported by the most voting power among those that pass
+// verify, ties going to the lowest bytes, so every node derives the same tx
+// from the commit. A bid without such a tx has none in the tally. A nil
+// verify trusts every tx, for callers only reading the bids' power.
+// Legacy JSON vote extensions fail the tally unless allowLegacy is set.
+func TallyVotes(ctx sdk.Context, txDecoder sdk.TxDecoder, verify TxVerifier, cdc codec.Codec, extCommit abci.ExtendedCommitInfo, allowLegacy bool) (VoteTally, error) {
+	tally := VoteTally{
+		BidPower: make(map[string]int64),
+		BidTxs:   make(map[string][]byte),
+		TxBids:   make(map[string][]string),
+	}
+	txPower := make(map[string]map[string]int64)
+	txBids := make(map[string][]string)
+	decoded := make(map[string]sdk.Tx)
+
+	for _, vote := range extCommit.Votes {
+		// Every validator in the set counts towards the total, whether or not it voted
+		tally.TotalPower += vote.Validator.Power
 
-	// Iterate through votes
-	var ve AppVoteExtension
-	for _, vote := range votes {
-		// Unmarshal to AppExt
-		err := json.Unmarshal(vote.VoteExtension, &ve)
+		// Absent and nil votes carry no signed extension
+		if vote.BlockIdFlag != cmtproto.BlockIDFlagCommit || len(vote.VoteExtension) == 0 {
+			continue
+		}
+
+		ve, err := UnmarshalVoteExtension(vote.VoteExtension)
 		if err != nil {
-			log.Error(fmt.Sprintf("❌ :: Error unmarshalling Vote Extension"))
+			return VoteTally{}, err
+		}
+		if ve.Version == LegacyJSONVersion && !allowLegacy {
+			return VoteTally{}, fmt.Errorf("legacy vote extension outside the migration window")
+		}
+
+		seen := make(map[string]bool)
+		count := func(bid *nstypes.MsgBid, txBytes []byte) error {
+			h, err := Hash(bid)
+			if err != nil {
+				return err
+			}
+			if txBytes != nil {
+				if txPower[h] == nil {
+					txPower[h] = make(map[string]int64)
+				}
+				if _, ok := txPower[h][string(txBytes)]; !ok {
+					txBids[string(txBytes)] = append(txBids[string(txBytes)], h)
+				}
+				txPower[h][string(txBytes)] += vote.Validator.Power
+			}
+			if !seen[h] {
+				seen[h] = true
+				tally.BidPower[h] += vote.Validator.Power
+			}
+			return nil
 		}
 
-		st.Height = int(ve.Height)
+		seenTxs := make(map[string]bool)
+		for _, txBytes := range ve.Txs {
+			if seenTxs[string(txBytes)] {
+				continue
+			}
+			seenTxs[string(txBytes)] = true
+			tx, err := txDecoder(txBytes)
+			if err != nil {
+				return VoteTally{}, err
+			}
+			decoded[string(txBytes)] = tx
+			for _, msg := range tx.GetMsgs() {
+				if bid, ok := msg.(*nstypes.MsgBid); ok {
+					if err := count(bid, txBytes); err != nil {
+						return VoteTally{}, err
+					}
+				}
+			}
+		}
+		for _, b := range ve.Bids {
+			var bid nstypes.MsgBid
+			if err := cdc.Unmarshal(b, &bid); err != nil {
+				return VoteTally{}, err
+			}
+			if err := count(&bid, nil); err != nil {
+				return VoteTally{}, err
+			}
+		}
+	}
 
-		// If Bids in VE, append to Special Transaction
-		if len(ve.Bids) > 0 {
-			log.Info("🛠️ :: Bids in VE")
-			for _, b := range ve.Bids {
-				st.Bids = append(st.Bids, b)
+	// Each tx is verified once, however many bids it carries
+	valid := make(map[string]bool)
+	for h, txs := range txPower {
+		best, bestPower := "", int64(-1)
+		for tx, power := range txs {
+			ok, checked := valid[tx]
+			if !checked {
+				ok = verify == nil || verify(ctx, decoded[tx]) == nil
+				valid[tx] = ok
+			}
+			if !ok {
+				continue
+			}
+			if power > bestPower || (power == bestPower && tx < best) {
+				best, bestPower = tx, power
 			}
 		}
+		if bestPower < 0 {
+			continue
+		}
+		tally.BidTxs[h] = []byte(best)
+		tally.TxBids[best] = txBids[best]
 	}
 
-	return st, nil
+	return tally, nil
 }
 
-func ValidateBids(txConfig client.TxConfig, veBids []nstypes.MsgBid, proposalTxs [][]byte, logger log.Logger) (bool, error) {
-	var proposalBids []*nstypes.MsgBid
+func proposalBids(txConfig client.TxConfig, proposalTxs [][]byte) ([]*nstypes.MsgBid, error) {
+	var bids []*nstypes.MsgBid
+	txDecoder := txConfig.TxDecoder()
 	for _, txBytes := range proposalTxs {
-		txDecoder := txConfig.TxDecoder()
 		messages, err := txDecoder(txBytes)
 		if err != nil {
-			logger.Error(fmt.Sprintf("❌️:: Unable to decode proposal transactions :: %v", err))
-
-			return false, err
+			return nil, err
 		}
 		sdkMsgs := messages.GetMsgs()
 		for _, m := range sdkMsgs {
 			switch m := m.(type) {
 			case *nstypes.MsgBid:
-				proposalBids = append(proposalBids, m)
+				bids = append(bids, m)
 			}
 		}
 	}
+	return bids, nil
+}
+
+// ProposalBidHashes returns the hashes of all bids contained in txs
+func ProposalBidHashes(txConfig client.TxConfig, txs [][]byte) (map[string]bool, error) {
+	bids, err := proposalBids(txConfig, txs)
+	if err != nil {
+		return nil, err
+	}
 
-	bidFreq := make(map[string]int)
-	totalVotes := len(veBids)
-	for _, b := range veBids {
-		h, err := Hash(&b)
+	hashes := make(map[string]bool, len(bids))
+	for _, b := range bids {
+		key, err := Hash(b)
 		if err != nil {
-			logger.Error(fmt.Sprintf("❌️:: Unable to produce bid frequency map :: %v", err))
-
-			return false, err
+			return nil, err
 		}
-		bidFreq[h]++
+		hashes[key] = true
+	}
+	return hashes, nil
+}
+
+func ValidateBids(txConfig client.TxConfig, tally VoteTally, threshold math.LegacyDec, proposalTxs [][]byte, logger log.Logger) (bool, error) {
+	proposalBids, err := proposalBids(txConfig, proposalTxs)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌️:: Unable to decode proposal transactions :: %v", err))
+
+		return false, err
 	}
 
-	thresholdCount := int(float64(totalVotes) * 0.5)
-	logger.Info(fmt.Sprintf("🛠️ :: VE Threshold: %v", thresholdCount))
+	// A bid must be observed by strictly more than threshold * total voting power
+	thresholdPower := threshold.MulInt64(tally.TotalPower)
+	logger.Info(fmt.Sprintf("🛠️ :: VE Threshold: %v of %v", thresholdPower, tally.TotalPower))
 	ok := true
 	logger.Info(fmt.Sprintf("🛠️ :: Number of Proposal Bids: %v", len(proposalBids)))
 
@@ -214,9 +394,9 @@ func ValidateBids(txConfig client.TxConfig, veBids []nstypes.MsgBid, proposalTxs
 
 			return false, err
 		}
-		freq := bidFreq[key]
-		logger.Info(fmt.Sprintf("🛠️ :: Frequency for Proposal Bid: %v", freq))
-		if freq < thresholdCount {
+		power := tally.BidPower[key]
+		logger.Info(fmt.Sprintf("🛠️ :: Voting power for Proposal Bid: %v", power))
+		if !math.LegacyNewDec(power).GT(thresholdPower) {
 			logger.Error(fmt.Sprintf("❌️:: Detected invalid proposal bid :: %v", p))
 
 			ok = false
@@ -225,6 +405,129 @@ func ValidateBids(txConfig client.TxConfig, veBids []nstypes.MsgBid, proposalTxs
 	return ok, nil
 }
 
+// CrossedBids returns the hashes of all bids observed by strictly more than
+// threshold of the voting power, the bids ValidateBids accepts in a proposal
+func CrossedBids(tally VoteTally, threshold math.LegacyDec) map[string]bool {
+	thresholdPower := threshold.MulInt64(tally.TotalPower)
+
+	crossed := make(map[string]bool)
+	for key, power := range tally.BidPower {
+		if math.LegacyNewDec(power).GT(thresholdPower) {
+			crossed[key] = true
+		}
+	}
+	return crossed
+}
+
+// RequiredBids returns the hashes of all bids observed by strictly more than
+// threshold of the voting power, in a deterministic order. Only bids with a tx
+// in the vote extensions are required, so any proposer can include them
+// whether or not the tx reached its own mempool. A tx that also carries a bid
+// short of the threshold would fail ValidateBids, its bids are not required.
+func RequiredBids(tally VoteTally, threshold math.LegacyDec) []string {
+	crossed := CrossedBids(tally, threshold)
+
+	var required []string
+	for key := range crossed {
+		txBytes, ok := tally.BidTxs[key]
+		if !ok {
+			continue
+		}
+		if bids, ok := tally.TxBids[string(txBytes)]; ok && !allCrossed(bids, crossed) {
+			continue
+		}
+		required = append(required, key)
+	}
+	sort.Strings(required)
+	return required
+}
+
+// requiredTxs returns the txs carrying the bids RequiredBids returns, each
+// once, along with the required bids each tx carries keyed by the encoded tx.
+// The txs follow the bids' order except that each signer's txs are in
+// sequence order, the order PrepareProposal adds them in and ValidateInclusion
+// replays them in. A tx that does not decode counts no gas and keeps its
+// place.
+func requiredTxs(txDecoder sdk.TxDecoder, tally VoteTally, threshold math.LegacyDec) ([]encodedTx, map[string][]string) {
+	// One tx may carry several required bids
+	var txs []encodedTx
+	var sdkTxs []sdk.Tx
+	txBids := make(map[string][]string)
+	for _, key := range RequiredBids(tally, threshold) {
+		bz := tally.BidTxs[key]
+		if _, ok := txBids[string(bz)]; !ok {
+			required := encodedTx{bz: bz}
+			if tx, err := txDecoder(bz); err == nil {
+				required = encodedTx{tx: tx, bz: bz, gas: txGas(tx), bids: bidHashes(tx)}
+			}
+			txs = append(txs, required)
+			sdkTxs = append(sdkTxs, required.tx)
+		}
+		txBids[string(bz)] = append(txBids[string(bz)], key)
+	}
+
+	ordered := make([]encodedTx, 0, len(txs))
+	for _, i := range mempool.SequenceOrder(sdkTxs) {
+		ordered = append(ordered, txs[i])
+	}
+	return ordered, txBids
+}
+
+func allCrossed(bids []string, crossed map[string]bool) bool {
+	for _, key := range bids {
+		if !crossed[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateInclusion checks that every bid which crossed the threshold at H-1
+// was included in the proposal. The txs carrying required bids are replayed
+// in the order PrepareProposal adds them, see requiredTxs, right after the
+// special tx whose space limits already exclude. Where they sit among the
+// proposal's txs does not matter, PrepareProposal moves them behind earlier
+// txs of their signers. An omitted tx is only excused when it does not fit in
+// the block's bytes or gas next to the required txs before it, other txs in
+// the proposal cannot take its place.
+func ValidateInclusion(txConfig client.TxConfig, tally VoteTally, threshold math.LegacyDec, proposalTxs [][]byte, limits BlockLimits, logger log.Logger) (bool, error) {
+	included, err := ProposalBidHashes(txConfig, proposalTxs)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌️:: Unable to decode proposal transactions :: %v", err))
+
+		return false, err
+	}
+
+	required, txBids := requiredTxs(txConfig.TxDecoder(), tally, threshold)
+
+	space := &blockSpace{maxGas: limits.MaxGas}
+	maxBytes := limits.MaxTxBytes
+	if maxBytes <= 0 {
+		maxBytes = gomath.MaxInt64
+	}
+
+	ok := true
+	for _, tx := range required {
+		var omitted []string
+		for _, key := range txBids[string(tx.bz)] {
+			if !included[key] {
+				omitted = append(omitted, key)
+			}
+		}
+		if len(omitted) == 0 {
+			space.use(tx.bz, tx.gas)
+			continue
+		}
+		if !space.add(tx.bz, tx.gas, maxBytes) {
+			logger.Info(fmt.Sprintf("🛠️ :: Required bids omitted from a full block :: %v", omitted))
+			continue
+		}
+		logger.Error(fmt.Sprintf("❌️:: Proposal censors bids seen by the threshold :: %v", omitted))
+		ok = false
+	}
+	return ok, nil
+}
+
 func Hash(m *nstypes.MsgBid) (string, error) {
 	b, err := json.Marshal(m)
 	if err != nil {
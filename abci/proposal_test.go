@@ -1,9 +1,18 @@
 package abci
 
 import (
+	"context"
 	"cosmossdk.io/log"
 	"cosmossdk.io/math"
+	"encoding/json"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
 	"github.com/fatal-fruit/cosmapp/testutils"
 	nstypes "github.com/fatal-fruit/ns/types"
 	"github.com/stretchr/testify/require"
@@ -26,43 +35,452 @@ func TestBidHash(t *testing.T) {
 	}
 }
 
-// TODO: Fix me
 func TestValidateProposal(t *testing.T) {
 	testEncConfig := testutils.MakeTestEncodingConfig()
-	testTxConfig := testEncConfig.TxConfig
+	testTxConfig := newSignedTxConfig(testEncConfig.TxConfig)
+	cdc := testEncConfig.Marshaler
 	logger := log.NewTestLogger(t)
-	var proposalTxs [][]byte
 
-	voteExtBids := []nstypes.MsgBid{
+	_, bidBz := buildTx(t, testTxConfig, nil, []sdk.Msg{newBid("bob.cosmos", testBidOwner)})
+	proposalTxs := [][]byte{bidBz}
+
+	tests := []struct {
+		name   string
+		votes  []abci.ExtendedVoteInfo
+		expect bool
+	}{
 		{
-			"bob.cosmos",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			sdk.Coins{sdk.NewCoin("uatom", math.NewInt(5))},
+			name: "bid seen by majority of stake",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 40, bidBz),
+				newVote(t, 2, 30, bidBz),
+				newVote(t, 2, 30),
+			},
+			expect: true,
 		},
 		{
-			"bob.cosmos",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			sdk.Coins{sdk.NewCoin("uatom", math.NewInt(5))},
+			name: "bid seen by minority of stake",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 40, bidBz),
+				newVote(t, 2, 30),
+				newVote(t, 2, 30),
+			},
+			expect: false,
 		},
 		{
-			"bob.cosmos",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			"cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j",
-			sdk.Coins{sdk.NewCoin("uatom", math.NewInt(5))},
+			name: "repeated bid from a single validator counts once",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 10, bidBz, bidBz, bidBz),
+				newVote(t, 2, 45),
+				newVote(t, 2, 45),
+			},
+			expect: false,
+		},
+		{
+			name: "absent votes count towards total power only",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 60, bidBz),
+				{Validator: abci.Validator{Power: 40}, VoteExtension: newVoteExt(t, 2, bidBz), BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+			},
+			expect: true,
+		},
+		{
+			name: "bid only seen by absent votes",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 40),
+				{Validator: abci.Validator{Power: 60}, VoteExtension: newVoteExt(t, 2, bidBz), BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+			},
+			expect: false,
 		},
+		{
+			name: "bid seen by exactly half of stake",
+			votes: []abci.ExtendedVoteInfo{
+				newVote(t, 2, 50, bidBz),
+				newVote(t, 2, 50),
+			},
+			expect: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tally, err := TallyVotes(sdk.Context{}, testTxConfig.TxDecoder(), nil, cdc, abci.ExtendedCommitInfo{Votes: tc.votes}, false)
+			require.NoError(t, err)
+
+			ok, err := ValidateBids(testTxConfig, tally, DefaultBidThreshold, proposalTxs, logger)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ok)
+		})
+	}
+}
+
+func TestTallyVoteTxs(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+
+	bid := newBid("bob.cosmos", testBidOwner)
+	key, err := Hash(bid)
+	require.NoError(t, err)
+
+	// Two txs carrying the same bid, as after a fee bump
+	_, low := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withFee(1))
+	_, high := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withFee(2))
+
+	// Legacy vote extensions carry the bid without its tx
+	bidBz, err := encCfg.Marshaler.Marshal(bid)
+	require.NoError(t, err)
+	legacyVote := func(power int64) abci.ExtendedVoteInfo {
+		bz, err := json.Marshal(legacyJSON{Height: 2, Bids: [][]byte{bidBz}})
+		require.NoError(t, err)
+		return abci.ExtendedVoteInfo{
+			Validator:     abci.Validator{Power: power},
+			VoteExtension: bz,
+			BlockIdFlag:   cmtproto.BlockIDFlagCommit,
+		}
 	}
 
-	builder := testTxConfig.NewTxBuilder()
-	builder.SetMsgs(&voteExtBids[0])
-	tx := builder.GetTx()
-	bz, err := testTxConfig.TxEncoder()(tx)
+	// The bid's power is summed across txs, its tx is the one most power reported
+	tally, err := TallyVotes(sdk.Context{}, txConfig.TxDecoder(), nil, encCfg.Marshaler, abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		newVote(t, 2, 30, low),
+		newVote(t, 2, 40, high, high),
+		newVote(t, 2, 30),
+	}}, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(70), tally.BidPower[key])
+	require.Equal(t, high, tally.BidTxs[key])
+	require.Equal(t, []string{key}, RequiredBids(tally, DefaultBidThreshold))
+
+	// A bid only legacy vote extensions saw counts towards the threshold but is
+	// never required, no proposer could build its tx
+	legacyCommit := abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		legacyVote(70),
+		newVote(t, 2, 30),
+	}}
+	tally, err = TallyVotes(sdk.Context{}, txConfig.TxDecoder(), nil, encCfg.Marshaler, legacyCommit, true)
 	require.NoError(t, err)
+	require.Equal(t, int64(70), tally.BidPower[key])
+	require.Empty(t, RequiredBids(tally, DefaultBidThreshold))
 
-	proposalTxs = append(proposalTxs, bz)
+	// Outside the migration window they are not counted at all
+	_, err = TallyVotes(sdk.Context{}, txConfig.TxDecoder(), nil, encCfg.Marshaler, legacyCommit, false)
+	require.Error(t, err)
 
-	ok, err := ValidateBids(testTxConfig, voteExtBids, proposalTxs, logger)
+	ok, err := ValidateBids(txConfig, tally, DefaultBidThreshold, [][]byte{low}, log.NewTestLogger(t))
 	require.NoError(t, err)
 	require.True(t, ok)
 }
+
+func TestTallyVoteTxsVerified(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+
+	bid := newBid("bob.cosmos", testBidOwner)
+	key, err := Hash(bid)
+	require.NoError(t, err)
+
+	// Byzantine validators report the bid in tampered txs, which fail
+	// verification
+	_, valid := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withFee(2))
+	_, tampered := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withFee(1), withMemo("tampered"))
+	_, forged := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withFee(3), withMemo("tampered"))
+	verify := func(_ sdk.Context, tx sdk.Tx) error {
+		if tx.(sdk.TxWithMemo).GetMemo() == "tampered" {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+
+	// The verified tx is required even though more power reported the others
+	tally, err := TallyVotes(sdk.Context{}, txConfig.TxDecoder(), verify, encCfg.Marshaler, abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		newVote(t, 2, 20, valid),
+		newVote(t, 2, 40, tampered),
+		newVote(t, 2, 40, forged),
+	}}, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), tally.BidPower[key])
+	require.Equal(t, valid, tally.BidTxs[key])
+	require.Equal(t, []string{key}, RequiredBids(tally, DefaultBidThreshold))
+
+	// A bid only reported in unverified txs crossed the threshold but has no
+	// tx a proposer could include, so it is not required
+	tally, err = TallyVotes(sdk.Context{}, txConfig.TxDecoder(), verify, encCfg.Marshaler, abci.ExtendedCommitInfo{Votes: []abci.ExtendedVoteInfo{
+		newVote(t, 2, 60, tampered),
+		newVote(t, 2, 40, forged),
+	}}, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), tally.BidPower[key])
+	require.NotContains(t, tally.BidTxs, key)
+	require.True(t, CrossedBids(tally, DefaultBidThreshold)[key])
+	require.Empty(t, RequiredBids(tally, DefaultBidThreshold))
+}
+
+func TestVerifyExtendedCommitMismatch(t *testing.T) {
+	lastCommit := abci.CommitInfo{
+		Round: 0,
+		Votes: []abci.VoteInfo{
+			{Validator: abci.Validator{Address: []byte("val1"), Power: 60}, BlockIdFlag: cmtproto.BlockIDFlagCommit},
+			{Validator: abci.Validator{Address: []byte("val2"), Power: 40}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+		},
+	}
+	extCommit := func() abci.ExtendedCommitInfo {
+		return abci.ExtendedCommitInfo{
+			Round: 0,
+			Votes: []abci.ExtendedVoteInfo{
+				{Validator: abci.Validator{Address: []byte("val1"), Power: 60}, BlockIdFlag: cmtproto.BlockIDFlagCommit},
+				{Validator: abci.Validator{Address: []byte("val2"), Power: 40}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		malleate func(c *abci.ExtendedCommitInfo)
+	}{
+		{"different round", func(c *abci.ExtendedCommitInfo) { c.Round = 1 }},
+		{"dropped vote", func(c *abci.ExtendedCommitInfo) { c.Votes = c.Votes[:1] }},
+		{"inflated power", func(c *abci.ExtendedCommitInfo) { c.Votes[0].Validator.Power = 100 }},
+		{"different validator", func(c *abci.ExtendedCommitInfo) { c.Votes[1].Validator.Address = []byte("val3") }},
+		{"absent vote marked as commit", func(c *abci.ExtendedCommitInfo) { c.Votes[1].BlockIdFlag = cmtproto.BlockIDFlagCommit }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := extCommit()
+			tc.malleate(&c)
+			err := verifyExtendedCommit(sdk.Context{}, nil, 3, c, lastCommit)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestProcessProposalSpecialTx(t *testing.T) {
+	testEncConfig := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(testEncConfig.TxConfig)
+	logger := log.NewTestLogger(t)
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 2},
+	})
+
+	wrongHeight, err := SpecialTransaction{Height: 1}.Marshal()
+	require.NoError(t, err)
+	noVotes, err := SpecialTransaction{Height: 2}.Marshal()
+	require.NoError(t, err)
+
+	_, bidBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "bob.cosmos")
+
+	tests := []struct {
+		name   string
+		height int64
+		txs    [][]byte
+		status abci.ResponseProcessProposal_ProposalStatus
+	}{
+		{"before vote extensions", 2, nil, abci.ResponseProcessProposal_ACCEPT},
+		// A block without bounds has room for the special tx
+		{"empty proposal", 3, nil, abci.ResponseProcessProposal_REJECT},
+		{"missing special tx", 3, [][]byte{bidBz}, abci.ResponseProcessProposal_REJECT},
+		{"garbage special tx", 3, [][]byte{[]byte("garbage")}, abci.ResponseProcessProposal_REJECT},
+		{"wrong height special tx", 4, [][]byte{wrongHeight}, abci.ResponseProcessProposal_REJECT},
+		{"no vote extensions", 3, [][]byte{noVotes}, abci.ResponseProcessProposal_ACCEPT},
+		// Bids are checked even when the vote extensions observed none
+		{"bid without vote extension evidence", 3, [][]byte{noVotes, bidBz}, abci.ResponseProcessProposal_REJECT},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewProcessProposalHandler(logger, txConfig, testEncConfig.Marshaler, nil, nil, paramstypes.Subspace{}, DefaultBidThreshold)
+			resp, err := h.ProcessProposalHandler()(ctx, &abci.RequestProcessProposal{
+				Height: tc.height,
+				Txs:    tc.txs,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.status, resp.Status)
+		})
+	}
+}
+
+func TestValidateInclusion(t *testing.T) {
+	testEncConfig := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+
+	tally := VoteTally{
+		TotalPower: 100,
+		BidPower: map[string]int64{
+			"seen-by-majority": 70,
+			"seen-by-minority": 30,
+		},
+		BidTxs: map[string][]byte{
+			"seen-by-majority": make([]byte, 100),
+			"seen-by-minority": make([]byte, 100),
+		},
+	}
+	require.Equal(t, []string{"seen-by-majority"}, RequiredBids(tally, DefaultBidThreshold))
+
+	// Omitting a required bid with room left in the block is censorship
+	ok, err := ValidateInclusion(testEncConfig.TxConfig, tally, DefaultBidThreshold, nil, BlockLimits{}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = ValidateInclusion(testEncConfig.TxConfig, tally, DefaultBidThreshold, nil, BlockLimits{MaxTxBytes: 1024 * 1024}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// A full block excuses the omission, the tx needs 102 bytes of block data
+	ok, err = ValidateInclusion(testEncConfig.TxConfig, tally, DefaultBidThreshold, nil, BlockLimits{MaxTxBytes: 102}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = ValidateInclusion(testEncConfig.TxConfig, tally, DefaultBidThreshold, nil, BlockLimits{MaxTxBytes: 101}, logger)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// As is a block the special tx used up, rather than leaving it unbounded
+	limits := BlockLimits{MaxTxBytes: 102}.without(make([]byte, 100))
+	ok, err = ValidateInclusion(testEncConfig.TxConfig, tally, DefaultBidThreshold, nil, limits, logger)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Nothing is required when no bid crossed the threshold
+	ok, err = ValidateInclusion(testEncConfig.TxConfig, tally, math.LegacyNewDecWithPrec(9, 1), nil, BlockLimits{}, logger)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestValidateInclusionGas(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+
+	tally := VoteTally{TotalPower: 100, BidPower: map[string]int64{}, BidTxs: map[string][]byte{}}
+	for _, name := range []string{"alice.cosmos", "bob.cosmos"} {
+		bid := newBid(name, testBidOwner)
+		key, err := Hash(bid)
+		require.NoError(t, err)
+		_, bz := buildTx(t, txConfig, nil, []sdk.Msg{bid}, withGas(100))
+		tally.BidPower[key] = 100
+		tally.BidTxs[key] = bz
+	}
+	required := RequiredBids(tally, DefaultBidThreshold)
+	require.Len(t, required, 2)
+	first, second := tally.BidTxs[required[0]], tally.BidTxs[required[1]]
+	_, send := newSendTx(t, txConfig, withGas(150))
+
+	// Required txs take the block's gas in order, the second one does not fit
+	// next to the first
+	ok, err := ValidateInclusion(txConfig, tally, DefaultBidThreshold, [][]byte{first}, BlockLimits{MaxGas: 150}, logger)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = ValidateInclusion(txConfig, tally, DefaultBidThreshold, [][]byte{first}, BlockLimits{MaxGas: 200}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Omitting the first leaves room for it, whatever took the gas instead
+	ok, err = ValidateInclusion(txConfig, tally, DefaultBidThreshold, [][]byte{second}, BlockLimits{MaxGas: 150}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = ValidateInclusion(txConfig, tally, DefaultBidThreshold, [][]byte{send}, BlockLimits{MaxGas: 150}, logger)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Without gas for any of them their omission is excused
+	ok, err = ValidateInclusion(txConfig, tally, DefaultBidThreshold, [][]byte{send}, BlockLimits{MaxGas: 50}, logger)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestProposalLimits(t *testing.T) {
+	require.Equal(t, BlockLimits{}, ProposalLimits(cmtproto.ConsensusParams{}, 4))
+
+	cp := cmtproto.ConsensusParams{
+		Block:    &cmtproto.BlockParams{MaxBytes: 1 << 20, MaxGas: 1000},
+		Evidence: &cmtproto.EvidenceParams{MaxBytes: 1 << 10},
+	}
+	// Matches what CometBFT offers a proposer with the whole evidence budget used
+	limits := ProposalLimits(cp, 4)
+	require.Equal(t, cmttypes.MaxDataBytes(1<<20, 1<<10, 4), limits.MaxTxBytes)
+	require.Equal(t, int64(1000), limits.MaxGas)
+
+	cp.Block.MaxBytes = -1
+	require.Equal(t, cmttypes.MaxDataBytes(cmttypes.MaxBlockSizeBytes, 1<<10, 4), ProposalLimits(cp, 4).MaxTxBytes)
+
+	// Params too small for a block leave no room for txs
+	cp.Block.MaxBytes = 100
+	require.Equal(t, int64(1), ProposalLimits(cp, 4).MaxTxBytes)
+
+	// So does a special tx taking all the room there is
+	special := make([]byte, 100)
+	require.Equal(t, int64(10), BlockLimits{MaxTxBytes: 112}.without(special).MaxTxBytes)
+	require.Equal(t, int64(1), BlockLimits{MaxTxBytes: 102}.without(special).MaxTxBytes)
+	require.Equal(t, int64(1), BlockLimits{MaxTxBytes: 50}.without(special).MaxTxBytes)
+	require.Equal(t, BlockLimits{}, BlockLimits{}.without(special))
+}
+
+func TestPrepareProposalLanes(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder(), mempool.WithLanes(IsBidTx))
+	h := NewPrepareProposalHandler(logger, txConfig, encCfg.Marshaler, mp, nil, false, nil, nil, paramstypes.Subspace{}, DefaultBidThreshold, nil, DefaultAuctionLaneShare)
+
+	size := func(bz []byte) int64 {
+		return cmttypes.ComputeProtoSizeForTxs([]cmttypes.Tx{bz})
+	}
+
+	prepare := func(maxTxBytes, maxGas int64) [][]byte {
+		ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{Block: &cmtproto.BlockParams{MaxGas: maxGas}})
+		res, err := h.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{Height: 2, MaxTxBytes: maxTxBytes})
+		require.NoError(t, err)
+		return res.Txs
+	}
+
+	// Without bids the default lane may use the whole block
+	send, sendBz := newSendTx(t, txConfig, withGas(100))
+	require.NoError(t, mp.Insert(context.Background(), send))
+	require.Equal(t, [][]byte{sendBz}, prepare(size(sendBz), -1))
+
+	bid1, bid1Bz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "alice.cosmos", withGas(100))
+	bid2, bid2Bz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "carol.cosmos", withGas(100))
+	for _, tx := range []sdk.Tx{bid1, bid2} {
+		require.NoError(t, mp.Insert(context.Background(), tx))
+		require.NoError(t, mp.Update(context.Background(), tx))
+	}
+	require.Equal(t, len(bid1Bz), len(bid2Bz))
+
+	// The auction lane is held to half the block, the default lane gets the rest
+	txs := prepare(2*size(bid1Bz)+size(sendBz), -1)
+	require.Len(t, txs, 2)
+	require.Contains(t, [][]byte{bid1Bz, bid2Bz}, txs[0])
+	require.Equal(t, sendBz, txs[1])
+
+	// Txs that do not fit are skipped rather than overflowing the block
+	require.Empty(t, prepare(size(sendBz)-1, -1))
+
+	// Each tx uses 100 gas, the send no longer fits after a bid
+	txs = prepare(2*size(bid1Bz)+size(sendBz), 150)
+	require.Len(t, txs, 1)
+	require.Contains(t, [][]byte{bid1Bz, bid2Bz}, txs[0])
+}
+
+func TestPrepareProposalLanesKeepSenderSequence(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder(), mempool.WithLanes(IsBidTx))
+	// No auction lane share, ready bids never fit
+	h := NewPrepareProposalHandler(logger, txConfig, encCfg.Marshaler, mp, nil, false, nil, nil, paramstypes.Subspace{}, DefaultBidThreshold, nil, math.LegacyZeroDec())
+
+	// Alice's bid is ready and her later send is in the default lane
+	alice := secp256k1.GenPrivKey().PubKey()
+	bid, _ := newBidTx(t, txConfig, alice, "alice.cosmos", withSequence(0))
+	require.NoError(t, mp.Insert(context.Background(), bid))
+	require.NoError(t, mp.Update(context.Background(), bid))
+	aliceSend, _ := newSendTxFrom(t, txConfig, alice, withSequence(1))
+	require.NoError(t, mp.Insert(context.Background(), aliceSend))
+	bobSend, bobSendBz := newSendTx(t, txConfig)
+	require.NoError(t, mp.Insert(context.Background(), bobSend))
+
+	// The share cuts the bid, so the send would fail on its sequence and waits
+	// for the bid to be committed
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{Block: &cmtproto.BlockParams{MaxGas: -1}})
+	res, err := h.PrepareProposalHandler()(ctx, &abci.RequestPrepareProposal{Height: 2, MaxTxBytes: 1 << 20})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{bobSendBz}, res.Txs)
+}
@@ -0,0 +1,107 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/collections"
+	"cosmossdk.io/log"
+	"errors"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth/signing"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	nstypes "github.com/fatal-fruit/ns/types"
+)
+
+// AccountKeeper defines the account state needed to recheck mempool txs
+type AccountKeeper interface {
+	GetAccount(ctx context.Context, addr sdk.AccAddress) sdk.AccountI
+}
+
+// NameRecords looks up the current on-chain record for a name. It is
+// satisfied by the nameservice keeper's name mapping.
+type NameRecords interface {
+	Get(ctx context.Context, name string) (nstypes.NameRecord, error)
+}
+
+type RecheckHandler struct {
+	logger  log.Logger
+	mempool *mempool.ThresholdMempool
+	ak      AccountKeeper
+	names   NameRecords
+}
+
+func NewRecheckHandler(lg log.Logger, mp *mempool.ThresholdMempool, ak AccountKeeper, names NameRecords) *RecheckHandler {
+	return &RecheckHandler{
+		logger:  lg,
+		mempool: mp,
+		ak:      ak,
+		names:   names,
+	}
+}
+
+// PrepareCheckStater expires mempool txs past their TTL and rechecks the rest
+// against the state committed in the last block, evicting txs that can no
+// longer be included.
+func (h *RecheckHandler) PrepareCheckStater() sdk.PrepareCheckStater {
+	return func(ctx sdk.Context) {
+		if expired := h.mempool.Expire(ctx.BlockHeight()); expired > 0 {
+			h.logger.Info(fmt.Sprintf("🛠️ :: Expired %v transactions from mempool at height %v", expired, ctx.BlockHeight()))
+		}
+
+		evicted := h.mempool.Recheck(ctx, h.CheckTx)
+		if evicted > 0 {
+			h.logger.Info(fmt.Sprintf("🛠️ :: Evicted %v stale transactions from mempool at height %v", evicted, ctx.BlockHeight()))
+		}
+	}
+}
+
+// CheckTx returns an error if tx uses an already consumed sequence or bids no
+// more than the current on-chain price of a name.
+func (h *RecheckHandler) CheckTx(ctx sdk.Context, tx sdk.Tx) error {
+	sigTx, ok := tx.(signing.SigVerifiableTx)
+	if !ok {
+		return fmt.Errorf("tx does not carry signatures")
+	}
+	signers, err := sigTx.GetSigners()
+	if err != nil {
+		return err
+	}
+	sigs, err := sigTx.GetSignaturesV2()
+	if err != nil {
+		return err
+	}
+	if len(sigs) != len(signers) {
+		return fmt.Errorf("tx has %d signers but %d signatures", len(signers), len(sigs))
+	}
+
+	for i, signer := range signers {
+		acc := h.ak.GetAccount(ctx, signer)
+		if acc == nil {
+			continue
+		}
+		if sigs[i].Sequence < acc.GetSequence() {
+			return fmt.Errorf("stale sequence %d for %s, account is at %d", sigs[i].Sequence, acc.GetAddress(), acc.GetSequence())
+		}
+	}
+
+	for _, msg := range tx.GetMsgs() {
+		bid, ok := msg.(*nstypes.MsgBid)
+		if !ok {
+			continue
+		}
+		record, err := h.names.Get(ctx, bid.Name)
+		if errors.Is(err, collections.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			// Keep the tx, a failed lookup says nothing about its validity
+			h.logger.Error(fmt.Sprintf("❌️ :: Unable to look up name %v: %v", bid.Name, err))
+			continue
+		}
+		if !bid.Amount.IsAllGT(record.Amount) {
+			return fmt.Errorf("bid of %v for %v has been outbid by %v", bid.Amount, bid.Name, record.Amount)
+		}
+	}
+
+	return nil
+}
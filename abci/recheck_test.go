@@ -0,0 +1,71 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/collections"
+	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type mockAccountKeeper map[string]uint64
+
+func (m mockAccountKeeper) GetAccount(_ context.Context, addr sdk.AccAddress) sdk.AccountI {
+	seq, ok := m[addr.String()]
+	if !ok {
+		return nil
+	}
+	acc := authtypes.NewBaseAccountWithAddress(addr)
+	acc.Sequence = seq
+	return acc
+}
+
+type mockNameRecords map[string]nstypes.NameRecord
+
+func (m mockNameRecords) Get(_ context.Context, name string) (nstypes.NameRecord, error) {
+	record, ok := m[name]
+	if !ok {
+		return nstypes.NameRecord{}, collections.ErrNotFound
+	}
+	return record, nil
+}
+
+func TestRecheckTx(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	pk := secp256k1.GenPrivKey().PubKey()
+	sender := sdk.AccAddress(pk.Address())
+
+	bidTx := func(amount int64, sequence uint64) sdk.Tx {
+		bid := newBid("bob.cosmos", sender.String())
+		bid.Amount = sdk.Coins{sdk.NewCoin("uatom", math.NewInt(amount))}
+		tx, _ := buildTx(t, txConfig, pk, []sdk.Msg{bid}, func(b client.TxBuilder) {
+			require.NoError(t, b.SetSignatures(signing.SignatureV2{
+				PubKey:   pk,
+				Data:     &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+				Sequence: sequence,
+			}))
+		})
+		return tx
+	}
+
+	ak := mockAccountKeeper{sender.String(): 3}
+	names := mockNameRecords{}
+	h := NewRecheckHandler(log.NewTestLogger(t), mempool.NewThresholdMempool(log.NewTestLogger(t), encCfg.TxConfig.TxEncoder()), ak, names)
+
+	require.NoError(t, h.CheckTx(sdk.Context{}, bidTx(5, 3)))
+	require.Error(t, h.CheckTx(sdk.Context{}, bidTx(5, 2)))
+
+	names["bob.cosmos"] = nstypes.NameRecord{Name: "bob.cosmos", Amount: sdk.Coins{sdk.NewCoin("uatom", math.NewInt(5))}}
+	require.Error(t, h.CheckTx(sdk.Context{}, bidTx(5, 3)))
+	require.NoError(t, h.CheckTx(sdk.Context{}, bidTx(6, 3)))
+}
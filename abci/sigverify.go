@@ -0,0 +1,86 @@
+package abci
+
+import (
+	"bytes"
+	txsigning "cosmossdk.io/x/tx/signing"
+	"fmt"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// TxVerifier checks the signatures of a tx reported in vote extensions.
+// Proposers and validators both pick the tx a required bid is included with
+// through it, so it may only depend on committed state.
+type TxVerifier func(ctx sdk.Context, tx sdk.Tx) error
+
+// NewSigVerifier returns a TxVerifier checking every signature of a tx
+// against its signer's account. A reported tx may follow other txs of the
+// same signer, so it is verified at the sequence it was signed for, which
+// must not be used already. An account without a public key is verified
+// against the key in the signature, which must match its address.
+func NewSigVerifier(ak AccountKeeper, handler *txsigning.HandlerMap) TxVerifier {
+	return func(ctx sdk.Context, tx sdk.Tx) error {
+		sigTx, ok := tx.(authsigning.Tx)
+		if !ok {
+			return fmt.Errorf("tx of type %T does not carry signatures", tx)
+		}
+		adaptableTx, ok := tx.(authsigning.V2AdaptableTx)
+		if !ok {
+			return fmt.Errorf("tx of type %T has no signing data", tx)
+		}
+		signers, err := sigTx.GetSigners()
+		if err != nil {
+			return err
+		}
+		sigs, err := sigTx.GetSignaturesV2()
+		if err != nil {
+			return err
+		}
+		if len(sigs) == 0 {
+			return fmt.Errorf("tx is not signed")
+		}
+		if len(sigs) != len(signers) {
+			return fmt.Errorf("tx has %d signers but %d signatures", len(signers), len(sigs))
+		}
+
+		txData := adaptableTx.GetSigningTxData()
+		for i, sig := range sigs {
+			acc := ak.GetAccount(ctx, signers[i])
+			if acc == nil {
+				return fmt.Errorf("unknown signer %s", sdk.AccAddress(signers[i]))
+			}
+			if sig.Sequence < acc.GetSequence() {
+				return fmt.Errorf("stale sequence %d for %s, account is at %d", sig.Sequence, acc.GetAddress(), acc.GetSequence())
+			}
+
+			pubKey := acc.GetPubKey()
+			if pubKey == nil {
+				pubKey = sig.PubKey
+				if pubKey == nil || !bytes.Equal(pubKey.Address(), signers[i]) {
+					return fmt.Errorf("no public key for signer %s", acc.GetAddress())
+				}
+			}
+			anyPk, err := codectypes.NewAnyWithValue(pubKey)
+			if err != nil {
+				return err
+			}
+
+			signerData := txsigning.SignerData{
+				Address:       acc.GetAddress().String(),
+				ChainID:       ctx.ChainID(),
+				AccountNumber: acc.GetAccountNumber(),
+				Sequence:      sig.Sequence,
+				PubKey: &anypb.Any{
+					TypeUrl: anyPk.TypeUrl,
+					Value:   anyPk.Value,
+				},
+			}
+			if err := authsigning.VerifySignature(ctx, pubKey, signerData, sig.Data, handler, txData); err != nil {
+				return fmt.Errorf("invalid signature of %s: %w", acc.GetAddress(), err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package abci
+
+import (
+	"context"
+	clienttx "github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSigVerifier(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := encCfg.TxConfig
+	priv := secp256k1.GenPrivKey()
+	sender := sdk.AccAddress(priv.PubKey().Address())
+
+	// sign builds a send from sender signed with key for chainID at seq
+	sign := func(key cryptotypes.PrivKey, chainID string, seq uint64) sdk.Tx {
+		builder := txConfig.NewTxBuilder()
+		require.NoError(t, builder.SetMsgs(banktypes.NewMsgSend(sender, sender, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)))))
+		require.NoError(t, builder.SetSignatures(signing.SignatureV2{
+			PubKey:   key.PubKey(),
+			Data:     &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+			Sequence: seq,
+		}))
+		signerData := authsigning.SignerData{
+			Address:  sender.String(),
+			ChainID:  chainID,
+			Sequence: seq,
+			PubKey:   key.PubKey(),
+		}
+		sig, err := clienttx.SignWithPrivKey(context.Background(), signing.SignMode_SIGN_MODE_DIRECT, signerData, builder, key, txConfig, seq)
+		require.NoError(t, err)
+		require.NoError(t, builder.SetSignatures(sig))
+		return builder.GetTx()
+	}
+
+	ak := mockAccountKeeper{sender.String(): 3}
+	verify := NewSigVerifier(ak, txConfig.SignModeHandler())
+	ctx := sdk.Context{}.WithContext(context.Background()).WithChainID("cosmapp")
+
+	// A tx may be signed for a sequence after the account's
+	require.NoError(t, verify(ctx, sign(priv, "cosmapp", 3)))
+	require.NoError(t, verify(ctx, sign(priv, "cosmapp", 4)))
+
+	// But not for a used one, another chain or by another key
+	require.Error(t, verify(ctx, sign(priv, "cosmapp", 2)))
+	require.Error(t, verify(ctx, sign(priv, "other", 3)))
+	require.Error(t, verify(ctx, sign(secp256k1.GenPrivKey(), "cosmapp", 3)))
+
+	// Nor by an unknown account, or without a signature
+	require.Error(t, NewSigVerifier(mockAccountKeeper{}, txConfig.SignModeHandler())(ctx, sign(priv, "cosmapp", 3)))
+	builder := txConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(banktypes.NewMsgSend(sender, sender, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)))))
+	require.Error(t, verify(ctx, builder.GetTx()))
+}
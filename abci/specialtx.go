@@ -0,0 +1,111 @@
+package abci
+
+import (
+	"context"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+/*
+	The special transaction is not an sdk.Tx, so the app's tx decoder is
+	wrapped with SpecialTxDecoder. It decodes the special transaction to a tx
+	holding a single MsgSpecialTransaction, which BaseApp routes to
+	SpecialTxStore and records as a successful tx. Its contents are persisted
+	by the PreBlocker before any tx runs, the msg only acknowledges it.
+
+	SpecialTxEncoder encodes that tx back to the special transaction, so the
+	mempool can hash it when BaseApp removes the block's txs. The mempool never
+	holds it, CheckTx rejects it as it carries no signatures.
+*/
+
+// specialTx is the sdk.Tx the special transaction decodes to
+type specialTx struct {
+	bz  []byte
+	msg *abciv1.MsgSpecialTransaction
+}
+
+var _ sdk.Tx = specialTx{}
+
+func (tx specialTx) GetMsgs() []sdk.Msg {
+	return []sdk.Msg{tx.msg}
+}
+
+func (tx specialTx) GetMsgsV2() ([]protov2.Message, error) {
+	name := protoreflect.FullName(gogoproto.MessageName(tx.msg))
+	desc, err := gogoproto.HybridResolver.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", name)
+	}
+
+	bz, err := gogoproto.Marshal(tx.msg)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err := protov2.Unmarshal(bz, msg); err != nil {
+		return nil, err
+	}
+	return []protov2.Message{msg}, nil
+}
+
+// SpecialTxAuthority returns the address MsgSpecialTransaction is attributed to
+func SpecialTxAuthority() sdk.AccAddress {
+	return authtypes.NewModuleAddress(ModuleName)
+}
+
+// SpecialTxDecoder decodes the special transaction to a tx executing
+// MsgSpecialTransaction, and any other tx with txDecoder
+func SpecialTxDecoder(txDecoder sdk.TxDecoder) sdk.TxDecoder {
+	return func(bz []byte) (sdk.Tx, error) {
+		st, err := UnmarshalSpecialTransaction(bz)
+		if err != nil {
+			return txDecoder(bz)
+		}
+		return specialTx{
+			bz: bz,
+			msg: &abciv1.MsgSpecialTransaction{
+				Authority: SpecialTxAuthority().String(),
+				Height:    int64(st.Height),
+			},
+		}, nil
+	}
+}
+
+// SpecialTxEncoder encodes txs from SpecialTxDecoder back to the special
+// transaction, and any other tx with txEncoder
+func SpecialTxEncoder(txEncoder sdk.TxEncoder) sdk.TxEncoder {
+	return func(tx sdk.Tx) ([]byte, error) {
+		if st, ok := tx.(specialTx); ok {
+			return st.bz, nil
+		}
+		return txEncoder(tx)
+	}
+}
+
+var _ abciv1.MsgServer = &SpecialTxStore{}
+
+// SpecialTransaction executes the msg of the special transaction. A regular
+// tx carrying the msg fails, only SpecialTxDecoder may create it.
+func (s *SpecialTxStore) SpecialTransaction(goCtx context.Context, msg *abciv1.MsgSpecialTransaction) (*abciv1.MsgSpecialTransactionResponse, error) {
+	ctx := sdk.UnwrapSDKContext(goCtx)
+
+	st, err := UnmarshalSpecialTransaction(ctx.TxBytes())
+	if err != nil {
+		return nil, fmt.Errorf("MsgSpecialTransaction is only valid in the special transaction: %w", err)
+	}
+	if int64(st.Height) != msg.Height || msg.Height != ctx.BlockHeight()-1 {
+		return nil, fmt.Errorf("special transaction height %d, expected %d", msg.Height, ctx.BlockHeight()-1)
+	}
+
+	return &abciv1.MsgSpecialTransactionResponse{}, nil
+}
@@ -0,0 +1,135 @@
+package abci
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	storetypes "cosmossdk.io/store/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	dbm "github.com/cosmos/cosmos-db"
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/runtime"
+	"github.com/cosmos/cosmos-sdk/std"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// memParamStore keeps the consensus params in memory
+type memParamStore struct {
+	cp *cmtproto.ConsensusParams
+}
+
+func (s *memParamStore) Get(_ context.Context) (cmtproto.ConsensusParams, error) {
+	if s.cp == nil {
+		return cmtproto.ConsensusParams{}, nil
+	}
+	return *s.cp, nil
+}
+
+func (s *memParamStore) Has(_ context.Context) (bool, error) { return s.cp != nil, nil }
+
+func (s *memParamStore) Set(_ context.Context, cp cmtproto.ConsensusParams) error {
+	s.cp = &cp
+	return nil
+}
+
+func TestSpecialTxEncoding(t *testing.T) {
+	encCfg := testutils.MakeEncodingConfig()
+	std.RegisterInterfaces(encCfg.InterfaceRegistry)
+	abciv1.RegisterInterfaces(encCfg.InterfaceRegistry)
+	txDecoder := SpecialTxDecoder(encCfg.TxConfig.TxDecoder())
+	txEncoder := SpecialTxEncoder(encCfg.TxConfig.TxEncoder())
+
+	bz, err := SpecialTransaction{Height: 2}.Marshal()
+	require.NoError(t, err)
+
+	tx, err := txDecoder(bz)
+	require.NoError(t, err)
+	msgs := tx.GetMsgs()
+	require.Len(t, msgs, 1)
+	msg, ok := msgs[0].(*abciv1.MsgSpecialTransaction)
+	require.True(t, ok)
+	require.Equal(t, int64(2), msg.Height)
+
+	// The msg is attributed to the module address
+	msgsV2, err := tx.GetMsgsV2()
+	require.NoError(t, err)
+	signers, err := encCfg.Marshaler.GetMsgV2Signers(msgsV2[0])
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{SpecialTxAuthority()}, signers)
+
+	encoded, err := txEncoder(tx)
+	require.NoError(t, err)
+	require.Equal(t, bz, encoded)
+
+	// Other txs use the wrapped decoder and encoder
+	builder := encCfg.TxConfig.NewTxBuilder()
+	builder.SetMemo("regular")
+	regular, err := encCfg.TxConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+	tx, err = txDecoder(regular)
+	require.NoError(t, err)
+	encoded, err = txEncoder(tx)
+	require.NoError(t, err)
+	require.Equal(t, regular, encoded)
+}
+
+func TestSpecialTxFinalizeBlock(t *testing.T) {
+	encCfg := testutils.MakeEncodingConfig()
+	std.RegisterInterfaces(encCfg.InterfaceRegistry)
+	abciv1.RegisterInterfaces(encCfg.InterfaceRegistry)
+	logger := log.NewTestLogger(t)
+
+	key := storetypes.NewKVStoreKey(StoreKey)
+	app := baseapp.NewBaseApp("test", logger, dbm.NewMemDB(), SpecialTxDecoder(encCfg.TxConfig.TxDecoder()))
+	app.SetInterfaceRegistry(encCfg.InterfaceRegistry)
+	app.SetParamStore(&memParamStore{})
+	app.MountStores(key)
+	s := NewSpecialTxStore(logger, runtime.NewKVStoreService(key), encCfg.TxConfig.TxDecoder(), encCfg.Marshaler, paramstypes.Subspace{}, DefaultBidThreshold, nil)
+	app.SetPreBlocker(s.PreBlocker())
+	abciv1.RegisterMsgServer(app.MsgServiceRouter(), s)
+	require.NoError(t, app.LoadLatestVersion())
+
+	_, err := app.InitChain(&abci.RequestInitChain{
+		InitialHeight: 3,
+		ConsensusParams: &cmtproto.ConsensusParams{
+			Block:     &cmtproto.BlockParams{MaxBytes: 1024 * 1024, MaxGas: -1},
+			Evidence:  &cmtproto.EvidenceParams{MaxAgeNumBlocks: 1, MaxAgeDuration: 1, MaxBytes: 1024},
+			Validator: &cmtproto.ValidatorParams{PubKeyTypes: []string{"ed25519"}},
+			Abci:      &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 2},
+		},
+	})
+	require.NoError(t, err)
+
+	special, err := SpecialTransaction{Height: 2}.Marshal()
+	require.NoError(t, err)
+	stale, err := SpecialTransaction{Height: 1}.Marshal()
+	require.NoError(t, err)
+
+	// A regular tx may not carry the msg of the special transaction
+	builder := encCfg.TxConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(&abciv1.MsgSpecialTransaction{
+		Authority: SpecialTxAuthority().String(),
+		Height:    2,
+	}))
+	forged, err := encCfg.TxConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+
+	res, err := app.FinalizeBlock(&abci.RequestFinalizeBlock{Height: 3, Txs: [][]byte{special, forged, stale}})
+	require.NoError(t, err)
+	require.Len(t, res.TxResults, 3)
+	require.Equal(t, uint32(0), res.TxResults[0].Code, res.TxResults[0].Log)
+	require.NotEqual(t, uint32(0), res.TxResults[1].Code)
+	require.NotEqual(t, uint32(0), res.TxResults[2].Code)
+
+	_, err = app.Commit()
+	require.NoError(t, err)
+
+	stored, found, err := s.GetLastSpecialTxRecord(app.NewContext(true))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, int64(2), stored.Height)
+}
@@ -0,0 +1,168 @@
+package abci
+
+import (
+	"cosmossdk.io/math"
+	"fmt"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// testBidOwner owns the bids of tests that do not depend on the bidder
+const testBidOwner = "cosmos1c3f2e2d4wwhaud70h3c7rah8aede8kplevxe3j"
+
+// signedTx reports fixed signers, independent of the msgs' signer annotations
+type signedTx struct {
+	authsigning.Tx
+	signers [][]byte
+}
+
+func (tx signedTx) GetSigners() ([][]byte, error) { return tx.signers, nil }
+
+// signedTxEncoder encodes signedTx by its wrapped tx
+func signedTxEncoder(enc sdk.TxEncoder) sdk.TxEncoder {
+	return func(tx sdk.Tx) ([]byte, error) {
+		stx, ok := tx.(signedTx)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tx type %T", tx)
+		}
+		return enc(stx.Tx)
+	}
+}
+
+// signedTxConfig encodes signedTx by its wrapped tx, and decodes the bytes it
+// encoded back to the same tx
+type signedTxConfig struct {
+	client.TxConfig
+	encoded map[string]sdk.Tx
+}
+
+func newSignedTxConfig(txConfig client.TxConfig) signedTxConfig {
+	return signedTxConfig{TxConfig: txConfig, encoded: make(map[string]sdk.Tx)}
+}
+
+func (c signedTxConfig) TxEncoder() sdk.TxEncoder {
+	enc := signedTxEncoder(c.TxConfig.TxEncoder())
+	return func(tx sdk.Tx) ([]byte, error) {
+		bz, err := enc(tx)
+		if err == nil {
+			c.encoded[string(bz)] = tx
+		}
+		return bz, err
+	}
+}
+
+func (c signedTxConfig) TxDecoder() sdk.TxDecoder {
+	return func(bz []byte) (sdk.Tx, error) {
+		if tx, ok := c.encoded[string(bz)]; ok {
+			return tx, nil
+		}
+		return c.TxConfig.TxDecoder()(bz)
+	}
+}
+
+// newBid returns a 5uatom bid by owner on name
+func newBid(name, owner string) *nstypes.MsgBid {
+	return &nstypes.MsgBid{
+		Name:           name,
+		Owner:          owner,
+		ResolveAddress: owner,
+		Amount:         sdk.Coins{sdk.NewCoin("uatom", math.NewInt(5))},
+	}
+}
+
+// buildTx builds a signedTx carrying msgs and encodes it with txConfig. The tx
+// is signed by pk unless pk is nil, set adjusts the builder before encoding.
+func buildTx(t *testing.T, txConfig signedTxConfig, pk cryptotypes.PubKey, msgs []sdk.Msg, set ...func(client.TxBuilder)) (sdk.Tx, []byte) {
+	builder := txConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(msgs...))
+
+	var signers [][]byte
+	if pk != nil {
+		require.NoError(t, builder.SetSignatures(signing.SignatureV2{
+			PubKey: pk,
+			Data:   &signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_DIRECT},
+		}))
+		signers = [][]byte{pk.Address()}
+	}
+	for _, s := range set {
+		s(builder)
+	}
+
+	tx := signedTx{builder.GetTx(), signers}
+	bz, err := txConfig.TxEncoder()(tx)
+	require.NoError(t, err)
+	return tx, bz
+}
+
+// newBidTx builds a tx in which pk's account bids on name, see buildTx
+func newBidTx(t *testing.T, txConfig signedTxConfig, pk cryptotypes.PubKey, name string, set ...func(client.TxBuilder)) (sdk.Tx, []byte) {
+	owner := sdk.AccAddress(pk.Address()).String()
+	return buildTx(t, txConfig, pk, []sdk.Msg{newBid(name, owner)}, set...)
+}
+
+// newSendTx builds a tx in which a fresh account sends 1uatom to itself, see
+// buildTx
+func newSendTx(t *testing.T, txConfig signedTxConfig, set ...func(client.TxBuilder)) (sdk.Tx, []byte) {
+	return newSendTxFrom(t, txConfig, secp256k1.GenPrivKey().PubKey(), set...)
+}
+
+// newSendTxFrom builds a tx in which pk's account sends 1uatom to itself, see
+// buildTx
+func newSendTxFrom(t *testing.T, txConfig signedTxConfig, pk cryptotypes.PubKey, set ...func(client.TxBuilder)) (sdk.Tx, []byte) {
+	sender := sdk.AccAddress(pk.Address())
+	msg := banktypes.NewMsgSend(sender, sender, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)))
+	return buildTx(t, txConfig, pk, []sdk.Msg{msg}, set...)
+}
+
+func withGas(gas uint64) func(client.TxBuilder) {
+	return func(b client.TxBuilder) { b.SetGasLimit(gas) }
+}
+
+func withFee(amount int64) func(client.TxBuilder) {
+	return func(b client.TxBuilder) { b.SetFeeAmount(sdk.NewCoins(sdk.NewInt64Coin("uatom", amount))) }
+}
+
+func withMemo(memo string) func(client.TxBuilder) {
+	return func(b client.TxBuilder) { b.SetMemo(memo) }
+}
+
+// withSequence sets the account sequence of the tx's signatures
+func withSequence(seq uint64) func(client.TxBuilder) {
+	return func(b client.TxBuilder) {
+		sigs, err := b.GetTx().GetSignaturesV2()
+		if err != nil {
+			return
+		}
+		for i := range sigs {
+			sigs[i].Sequence = seq
+		}
+		_ = b.SetSignatures(sigs...)
+	}
+}
+
+// newVoteExt returns the encoded vote extension for height reporting txs
+func newVoteExt(t *testing.T, height int64, txs ...[]byte) []byte {
+	bz, err := AppVoteExtension{Height: height, Txs: txs}.Marshal()
+	require.NoError(t, err)
+	return bz
+}
+
+// newVote returns the committed vote of a validator with power, whose vote
+// extension for height reports txs
+func newVote(t *testing.T, height, power int64, txs ...[]byte) abci.ExtendedVoteInfo {
+	return abci.ExtendedVoteInfo{
+		Validator:     abci.Validator{Power: power},
+		VoteExtension: newVoteExt(t, height, txs...),
+		BlockIdFlag:   cmtproto.BlockIDFlagCommit,
+	}
+}
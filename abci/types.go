@@ -1,9 +1,14 @@
 package abci
 
 import (
+	"context"
 	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/codec"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 	"github.com/fatal-fruit/cosmapp/mempool"
 	"github.com/fatal-fruit/cosmapp/provider"
 )
@@ -16,12 +21,28 @@ type PrepareProposalHandler struct {
 	txProvider  provider.TxProvider
 	keyname     string
 	runProvider bool
+	valStore    baseapp.ValidatorStore
+	// txVerifier picks the txs required bids are included with
+	txVerifier TxVerifier
+	paramSpace paramstypes.Subspace
+	// bidThreshold is the node's default, the on-chain param overrides it
+	bidThreshold math.LegacyDec
+	promoter     *BidPromoter
+	// auctionShare is the fraction of block space reserved for the auction lane
+	auctionShare math.LegacyDec
 }
 
 type ProcessProposalHandler struct {
 	TxConfig client.TxConfig
 	Codec    codec.Codec
 	Logger   log.Logger
+	ValStore baseapp.ValidatorStore
+	// TxVerifier picks the txs required bids are included with, as in
+	// PrepareProposal
+	TxVerifier TxVerifier
+	ParamSpace paramstypes.Subspace
+	// BidThreshold is the node's default, the on-chain param overrides it
+	BidThreshold math.LegacyDec
 }
 
 type VoteExtHandler struct {
@@ -29,23 +50,42 @@ type VoteExtHandler struct {
 	currentBlock int64
 	mempool      *mempool.ThresholdMempool
 	cdc          codec.Codec
+	txConfig     client.TxConfig
+	staking      StakingParams
+	paramSpace   paramstypes.Subspace
 }
 
-type InjectedVoteExt struct {
-	VoteExtSigner []byte
-	Bids          [][]byte
-}
-
-type InjectedVotes struct {
-	Votes []InjectedVoteExt
+// StakingParams reports the staking params vote extensions are sized by. It
+// is implemented by the staking keeper.
+type StakingParams interface {
+	MaxValidators(ctx context.Context) (uint32, error)
 }
 
 type AppVoteExtension struct {
-	Height int64
-	Bids   [][]byte
+	Version uint32
+	Height  int64
+	// Txs are the encoded txs carrying the observed bids
+	Txs [][]byte
+	// Bids are only set when decoded from the legacy JSON form, which carried
+	// the encoded bids without their txs
+	Bids [][]byte
 }
 
 type SpecialTransaction struct {
-	Height int
-	Bids   [][]byte
+	Version            uint32
+	Height             int
+	ExtendedCommitInfo abci.ExtendedCommitInfo
+}
+
+// VoteTally is the voting power that observed each bid in the H-1 vote extensions
+type VoteTally struct {
+	TotalPower int64
+	BidPower   map[string]int64
+	// BidTxs holds an encoded tx carrying each bid, the verified one reported
+	// by the most voting power. Bids only seen in legacy vote extensions or
+	// only in txs failing verification have none.
+	BidTxs map[string][]byte
+	// TxBids holds the hashes of the bids carried by each tx in BidTxs, keyed
+	// by the encoded tx
+	TxBids map[string][]string
 }
@@ -0,0 +1,1032 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: cosmapp/abci/v1/query.proto
+
+package types
+
+import (
+	context "context"
+	fmt "fmt"
+	_ "github.com/cosmos/gogoproto/gogoproto"
+	grpc1 "github.com/cosmos/gogoproto/grpc"
+	proto "github.com/cosmos/gogoproto/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type SpecialTxRecord struct {
+	Height     int64        `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	TotalPower int64        `protobuf:"varint,2,opt,name=total_power,json=totalPower,proto3" json:"total_power,omitempty"`
+	Bids       []TalliedBid `protobuf:"bytes,3,rep,name=bids,proto3" json:"bids"`
+}
+
+func (m *SpecialTxRecord) Reset()         { *m = SpecialTxRecord{} }
+func (m *SpecialTxRecord) String() string { return proto.CompactTextString(m) }
+func (*SpecialTxRecord) ProtoMessage()    {}
+func (*SpecialTxRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8ebd500a7cf496db, []int{0}
+}
+func (m *SpecialTxRecord) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SpecialTxRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SpecialTxRecord.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SpecialTxRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SpecialTxRecord.Merge(m, src)
+}
+func (m *SpecialTxRecord) XXX_Size() int {
+	return m.Size()
+}
+func (m *SpecialTxRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_SpecialTxRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SpecialTxRecord proto.InternalMessageInfo
+
+func (m *SpecialTxRecord) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *SpecialTxRecord) GetTotalPower() int64 {
+	if m != nil {
+		return m.TotalPower
+	}
+	return 0
+}
+
+func (m *SpecialTxRecord) GetBids() []TalliedBid {
+	if m != nil {
+		return m.Bids
+	}
+	return nil
+}
+
+type TalliedBid struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Power int64  `protobuf:"varint,2,opt,name=power,proto3" json:"power,omitempty"`
+}
+
+func (m *TalliedBid) Reset()         { *m = TalliedBid{} }
+func (m *TalliedBid) String() string { return proto.CompactTextString(m) }
+func (*TalliedBid) ProtoMessage()    {}
+func (*TalliedBid) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8ebd500a7cf496db, []int{1}
+}
+func (m *TalliedBid) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TalliedBid) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TalliedBid.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TalliedBid) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TalliedBid.Merge(m, src)
+}
+func (m *TalliedBid) XXX_Size() int {
+	return m.Size()
+}
+func (m *TalliedBid) XXX_DiscardUnknown() {
+	xxx_messageInfo_TalliedBid.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TalliedBid proto.InternalMessageInfo
+
+func (m *TalliedBid) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *TalliedBid) GetPower() int64 {
+	if m != nil {
+		return m.Power
+	}
+	return 0
+}
+
+type QueryLastSpecialTransactionRequest struct {
+}
+
+func (m *QueryLastSpecialTransactionRequest) Reset()         { *m = QueryLastSpecialTransactionRequest{} }
+func (m *QueryLastSpecialTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryLastSpecialTransactionRequest) ProtoMessage()    {}
+func (*QueryLastSpecialTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8ebd500a7cf496db, []int{2}
+}
+func (m *QueryLastSpecialTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryLastSpecialTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryLastSpecialTransactionRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryLastSpecialTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryLastSpecialTransactionRequest.Merge(m, src)
+}
+func (m *QueryLastSpecialTransactionRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryLastSpecialTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryLastSpecialTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryLastSpecialTransactionRequest proto.InternalMessageInfo
+
+type QueryLastSpecialTransactionResponse struct {
+	Record SpecialTxRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record"`
+	Found  bool            `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+}
+
+func (m *QueryLastSpecialTransactionResponse) Reset()         { *m = QueryLastSpecialTransactionResponse{} }
+func (m *QueryLastSpecialTransactionResponse) String() string { return proto.CompactTextString(m) }
+func (*QueryLastSpecialTransactionResponse) ProtoMessage()    {}
+func (*QueryLastSpecialTransactionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8ebd500a7cf496db, []int{3}
+}
+func (m *QueryLastSpecialTransactionResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryLastSpecialTransactionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryLastSpecialTransactionResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryLastSpecialTransactionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryLastSpecialTransactionResponse.Merge(m, src)
+}
+func (m *QueryLastSpecialTransactionResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryLastSpecialTransactionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryLastSpecialTransactionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryLastSpecialTransactionResponse proto.InternalMessageInfo
+
+func (m *QueryLastSpecialTransactionResponse) GetRecord() SpecialTxRecord {
+	if m != nil {
+		return m.Record
+	}
+	return SpecialTxRecord{}
+}
+
+func (m *QueryLastSpecialTransactionResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*SpecialTxRecord)(nil), "cosmapp.abci.v1.SpecialTxRecord")
+	proto.RegisterType((*TalliedBid)(nil), "cosmapp.abci.v1.TalliedBid")
+	proto.RegisterType((*QueryLastSpecialTransactionRequest)(nil), "cosmapp.abci.v1.QueryLastSpecialTransactionRequest")
+	proto.RegisterType((*QueryLastSpecialTransactionResponse)(nil), "cosmapp.abci.v1.QueryLastSpecialTransactionResponse")
+}
+
+func init() { proto.RegisterFile("cosmapp/abci/v1/query.proto", fileDescriptor_8ebd500a7cf496db) }
+
+var fileDescriptor_8ebd500a7cf496db = []byte{
+	// 370 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xbf, 0x6f, 0xda, 0x40,
+	0x14, 0xc7, 0x7d, 0x35, 0xa0, 0xf6, 0x31, 0x50, 0x9d, 0x10, 0x42, 0x20, 0x19, 0xe4, 0x76, 0xa0,
+	0x43, 0x6d, 0xf1, 0xa3, 0x6b, 0x07, 0xba, 0x76, 0x68, 0x1d, 0xa6, 0x2c, 0xd1, 0xd9, 0x3e, 0xcc,
+	0x29, 0xc6, 0x67, 0x7c, 0x67, 0x12, 0x94, 0x29, 0xca, 0x1c, 0x29, 0x7f, 0x16, 0x23, 0x63, 0xa6,
+	0x28, 0x82, 0x7f, 0x24, 0xf2, 0xd9, 0x51, 0x12, 0x88, 0x12, 0x65, 0xbb, 0xf7, 0xbe, 0xef, 0xe9,
+	0xfb, 0xfd, 0x9c, 0x1e, 0xb4, 0x3d, 0x2e, 0xe6, 0x24, 0x8e, 0x6d, 0xe2, 0x7a, 0xcc, 0x5e, 0xf6,
+	0xed, 0x45, 0x4a, 0x93, 0x95, 0x15, 0x27, 0x5c, 0x72, 0x5c, 0x2b, 0x44, 0x2b, 0x13, 0xad, 0x65,
+	0xbf, 0x55, 0x0f, 0x78, 0xc0, 0x95, 0x66, 0x67, 0xaf, 0x7c, 0xcc, 0xbc, 0x44, 0x50, 0x3b, 0x8a,
+	0xa9, 0xc7, 0x48, 0x38, 0x39, 0x77, 0xa8, 0xc7, 0x13, 0x1f, 0x37, 0xa0, 0x32, 0xa3, 0x2c, 0x98,
+	0xc9, 0x26, 0xea, 0xa2, 0x9e, 0xee, 0x14, 0x15, 0xee, 0x40, 0x55, 0x72, 0x49, 0xc2, 0x93, 0x98,
+	0x9f, 0xd1, 0xa4, 0xf9, 0x49, 0x89, 0xa0, 0x5a, 0xff, 0xb2, 0x0e, 0xfe, 0x05, 0x25, 0x97, 0xf9,
+	0xa2, 0xa9, 0x77, 0xf5, 0x5e, 0x75, 0xd0, 0xb6, 0xf6, 0x22, 0x58, 0x13, 0x12, 0x86, 0x8c, 0xfa,
+	0x63, 0xe6, 0x8f, 0x4b, 0xeb, 0xbb, 0x8e, 0xe6, 0xa8, 0x71, 0x73, 0x04, 0xf0, 0xa4, 0xe0, 0xaf,
+	0xa0, 0x9f, 0xd2, 0x95, 0xb2, 0xfe, 0xe2, 0x64, 0x4f, 0x5c, 0x87, 0xf2, 0x73, 0xc7, 0xbc, 0x30,
+	0xbf, 0x83, 0xf9, 0x3f, 0xe3, 0xfd, 0x4b, 0x84, 0x7c, 0x24, 0x48, 0x48, 0x24, 0x88, 0x27, 0x19,
+	0x8f, 0x1c, 0xba, 0x48, 0xa9, 0x90, 0xe6, 0x05, 0x7c, 0x7b, 0x73, 0x4a, 0xc4, 0x3c, 0x12, 0x14,
+	0xff, 0x86, 0x4a, 0xa2, 0xe0, 0x95, 0x6f, 0x75, 0xd0, 0x3d, 0xc8, 0xbe, 0xf7, 0x49, 0x05, 0x40,
+	0xb1, 0x95, 0x45, 0x9c, 0xf2, 0x34, 0xf2, 0x55, 0xc4, 0xcf, 0x4e, 0x5e, 0x0c, 0xae, 0x11, 0x94,
+	0x95, 0x3b, 0xbe, 0x42, 0xd0, 0x78, 0x3d, 0x02, 0x1e, 0x1e, 0x58, 0xbd, 0x8f, 0xd5, 0x1a, 0x7d,
+	0x6c, 0x29, 0xa7, 0x1c, 0xff, 0x59, 0x6f, 0x0d, 0xb4, 0xd9, 0x1a, 0xe8, 0x7e, 0x6b, 0xa0, 0x9b,
+	0x9d, 0xa1, 0x6d, 0x76, 0x86, 0x76, 0xbb, 0x33, 0xb4, 0xe3, 0x1f, 0x01, 0x93, 0xb3, 0xd4, 0xb5,
+	0x3c, 0x3e, 0xb7, 0xa7, 0x44, 0x92, 0xf0, 0xe7, 0x34, 0x49, 0x99, 0xb4, 0x5f, 0x5c, 0x98, 0x5c,
+	0xc5, 0x54, 0xb8, 0x15, 0x75, 0x38, 0xc3, 0x87, 0x01, 0x00, 0x59, 0xd9, 0x05, 0x3e, 0x7e, 0x02,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type QueryClient interface {
+	LastSpecialTransaction(ctx context.Context, in *QueryLastSpecialTransactionRequest, opts ...grpc.CallOption) (*QueryLastSpecialTransactionResponse, error)
+}
+
+type queryClient struct {
+	cc grpc1.ClientConn
+}
+
+func NewQueryClient(cc grpc1.ClientConn) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) LastSpecialTransaction(ctx context.Context, in *QueryLastSpecialTransactionRequest, opts ...grpc.CallOption) (*QueryLastSpecialTransactionResponse, error) {
+	out := new(QueryLastSpecialTransactionResponse)
+	err := c.cc.Invoke(ctx, "/cosmapp.abci.v1.Query/LastSpecialTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServer is the server API for Query service.
+type QueryServer interface {
+	LastSpecialTransaction(context.Context, *QueryLastSpecialTransactionRequest) (*QueryLastSpecialTransactionResponse, error)
+}
+
+// UnimplementedQueryServer can be embedded to have forward compatible implementations.
+type UnimplementedQueryServer struct {
+}
+
+func (*UnimplementedQueryServer) LastSpecialTransaction(ctx context.Context, req *QueryLastSpecialTransactionRequest) (*QueryLastSpecialTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LastSpecialTransaction not implemented")
+}
+
+func RegisterQueryServer(s grpc1.Server, srv QueryServer) {
+	s.RegisterService(&_Query_serviceDesc, srv)
+}
+
+func _Query_LastSpecialTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryLastSpecialTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).LastSpecialTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cosmapp.abci.v1.Query/LastSpecialTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).LastSpecialTransaction(ctx, req.(*QueryLastSpecialTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Query_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "cosmapp.abci.v1.Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LastSpecialTransaction",
+			Handler:    _Query_LastSpecialTransaction_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cosmapp/abci/v1/query.proto",
+}
+
+func (m *SpecialTxRecord) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SpecialTxRecord) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SpecialTxRecord) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Bids) > 0 {
+		for iNdEx := len(m.Bids) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Bids[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintQuery(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.TotalPower != 0 {
+		i = encodeVarintQuery(dAtA, i, uint64(m.TotalPower))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Height != 0 {
+		i = encodeVarintQuery(dAtA, i, uint64(m.Height))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TalliedBid) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TalliedBid) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TalliedBid) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Power != 0 {
+		i = encodeVarintQuery(dAtA, i, uint64(m.Power))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintQuery(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QueryLastSpecialTransactionRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryLastSpecialTransactionRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryLastSpecialTransactionRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *QueryLastSpecialTransactionResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryLastSpecialTransactionResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryLastSpecialTransactionResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Found {
+		i--
+		if m.Found {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	{
+		size, err := m.Record.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintQuery(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintQuery(dAtA []byte, offset int, v uint64) int {
+	offset -= sovQuery(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SpecialTxRecord) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Height != 0 {
+		n += 1 + sovQuery(uint64(m.Height))
+	}
+	if m.TotalPower != 0 {
+		n += 1 + sovQuery(uint64(m.TotalPower))
+	}
+	if len(m.Bids) > 0 {
+		for _, e := range m.Bids {
+			l = e.Size()
+			n += 1 + l + sovQuery(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TalliedBid) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovQuery(uint64(l))
+	}
+	if m.Power != 0 {
+		n += 1 + sovQuery(uint64(m.Power))
+	}
+	return n
+}
+
+func (m *QueryLastSpecialTransactionRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *QueryLastSpecialTransactionResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = m.Record.Size()
+	n += 1 + l + sovQuery(uint64(l))
+	if m.Found {
+		n += 2
+	}
+	return n
+}
+
+func sovQuery(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozQuery(x uint64) (n int) {
+	return sovQuery(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *SpecialTxRecord) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SpecialTxRecord: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SpecialTxRecord: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Height", wireType)
+			}
+			m.Height = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Height |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalPower", wireType)
+			}
+			m.TotalPower = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalPower |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bids", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bids = append(m.Bids, TalliedBid{})
+			if err := m.Bids[len(m.Bids)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TalliedBid) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TalliedBid: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TalliedBid: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthQuery
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Power", wireType)
+			}
+			m.Power = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Power |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryLastSpecialTransactionRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryLastSpecialTransactionRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryLastSpecialTransactionRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryLastSpecialTransactionResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryLastSpecialTransactionResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryLastSpecialTransactionResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Record.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Found", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Found = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipQuery(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowQuery
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowQuery
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthQuery
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupQuery
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthQuery
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthQuery        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowQuery          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupQuery = fmt.Errorf("proto: unexpected end of group")
+)
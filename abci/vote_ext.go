@@ -3,20 +3,82 @@ package abci
 import (
 	"context"
 	"cosmossdk.io/log"
-	"encoding/json"
 	"fmt"
 	abci "github.com/cometbft/cometbft/abci/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkmempool "github.com/cosmos/cosmos-sdk/types/mempool"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 	"github.com/fatal-fruit/cosmapp/mempool"
 	nstypes "github.com/fatal-fruit/ns/types"
 )
 
-func NewVoteExtensionHandler(lg log.Logger, mp *mempool.ThresholdMempool, cdc codec.Codec) *VoteExtHandler {
+const (
+	// MaxVoteExtBids is the maximum number of bids a single vote extension may carry
+	MaxVoteExtBids = 256
+	// MaxVoteExtSize is the maximum size in bytes of an encoded vote extension,
+	// VoteExtLimit lowers it for smaller blocks
+	MaxVoteExtSize = 256 * 1024
+)
+
+// VoteExtLimit returns the maximum size in bytes of a vote extension under the
+// current params. The special tx embeds every validator's extension, so a
+// full validator set's extensions are held to half the block and the rest is
+// left for their signatures and the block's txs.
+func VoteExtLimit(ctx sdk.Context, staking StakingParams) (int, error) {
+	cp := ctx.ConsensusParams()
+	if cp.Block == nil {
+		return MaxVoteExtSize, nil
+	}
+	maxBytes := cp.Block.MaxBytes
+	if maxBytes == -1 {
+		maxBytes = cmttypes.MaxBlockSizeBytes
+	}
+	if maxBytes <= 0 {
+		return MaxVoteExtSize, nil
+	}
+
+	maxVals, err := staking.MaxValidators(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if maxVals == 0 {
+		return MaxVoteExtSize, nil
+	}
+
+	limit := maxBytes / 2 / int64(maxVals)
+	if limit > MaxVoteExtSize {
+		return MaxVoteExtSize, nil
+	}
+	return int(limit), nil
+}
+
+// VoteExtensionsEnabled reports whether validators extend their votes at
+// height, according to the consensus params' VoteExtensionsEnableHeight.
+func VoteExtensionsEnabled(ctx sdk.Context, height int64) bool {
+	cp := ctx.ConsensusParams()
+	if cp.Abci == nil || cp.Abci.VoteExtensionsEnableHeight == 0 {
+		return false
+	}
+	return height >= cp.Abci.VoteExtensionsEnableHeight
+}
+
+// SpecialTxExpected reports whether a proposal at height must carry a special
+// transaction, i.e. vote extensions were produced at the previous height.
+func SpecialTxExpected(ctx sdk.Context, height int64) bool {
+	return VoteExtensionsEnabled(ctx, height-1)
+}
+
+func NewVoteExtensionHandler(lg log.Logger, mp *mempool.ThresholdMempool, cdc codec.Codec, txCg client.TxConfig, staking StakingParams, ps paramstypes.Subspace) *VoteExtHandler {
 	return &VoteExtHandler{
-		logger:  lg,
-		mempool: mp,
-		cdc:     cdc,
+		logger:     lg,
+		mempool:    mp,
+		cdc:        cdc,
+		txConfig:   txCg,
+		staking:    staking,
+		paramSpace: ps,
 	}
 }
 
@@ -24,50 +86,109 @@ func (h *VoteExtHandler) ExtendVoteHandler() sdk.ExtendVoteHandler {
 	return func(ctx sdk.Context, req *abci.RequestExtendVote) (*abci.ResponseExtendVote, error) {
 		h.logger.Info(fmt.Sprintf("Extending votes at block height : %v", req.Height))
 
-		voteExtBids := [][]byte{}
+		if !VoteExtensionsEnabled(ctx, req.Height) {
+			h.logger.Info(fmt.Sprintf("Vote extensions not enabled at block height : %v", req.Height))
+			return &abci.ResponseExtendVote{}, nil
+		}
 
-		// Get mempool txs
-		itr := h.mempool.SelectPending(context.Background(), nil)
+		limit, err := VoteExtLimit(ctx, h.staking)
+		if err != nil {
+			// An empty extension is always valid
+			h.logger.Error(fmt.Sprintf("❌ :: Unable to size vote extension : %v", err))
+			return &abci.ResponseExtendVote{}, nil
+		}
 
-		for itr != nil {
-			tmptx := itr.Tx()
-			sdkMsgs := tmptx.GetMsgs()
+		voteExtTxs := [][]byte{}
+		numBids := 0
+		size := AppVoteExtension{Height: req.Height}.Size()
 
-			// Iterate through msgs, check for any bids
-			for _, msg := range sdkMsgs {
-				switch msg := msg.(type) {
-				case *nstypes.MsgBid:
-					// Marshal sdk bids to []byte
-					bz, err := h.cdc.Marshal(msg)
-					if err != nil {
-						h.logger.Error(fmt.Sprintf("Error marshalling VE Bid : %v", err))
-						break
+		// Bids in the block being voted on are committed at this height and
+		// must not be reported, or they would be required again at H+1
+		blockTxs := req.Txs
+		if len(blockTxs) > 0 && IsSpecialTransaction(blockTxs[0]) {
+			blockTxs = blockTxs[1:]
+		}
+		committing, err := ProposalBidHashes(h.txConfig, blockTxs)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("Error decoding block txs : %v", err))
+		}
+
+		// Only block txs the mempool holds can turn up in its iterators, so
+		// look them up once instead of having each iterator hash every one
+		var held [][]byte
+		for _, bz := range blockTxs {
+			if h.mempool.Contains(mempool.TxHash(bz)) {
+				held = append(held, bz)
+			}
+		}
+
+		// Report pending txs not already in the block, and ready ones too so
+		// bids promoted earlier keep the evidence proposals need to include them
+		itrs := []sdkmempool.Iterator{
+			h.mempool.SelectPending(context.Background(), held),
+			h.mempool.Select(context.Background(), held),
+		}
+
+	collect:
+		for _, itr := range itrs {
+			for ; itr != nil; itr = itr.Next() {
+				tmptx := itr.Tx()
+				sdkMsgs := tmptx.GetMsgs()
+
+				// Iterate through msgs, count the bids
+				txBids := 0
+				inBlock := false
+				for _, msg := range sdkMsgs {
+					switch msg := msg.(type) {
+					case *nstypes.MsgBid:
+						if key, err := Hash(msg); err == nil && committing[key] {
+							inBlock = true
+						}
+						txBids++
+					default:
 					}
-					voteExtBids = append(voteExtBids, bz)
-				default:
 				}
-			}
+				if inBlock || txBids == 0 {
+					continue
+				}
 
-			// Move tx to ready pool
-			err := h.mempool.Update(context.Background(), tmptx)
+				// Stop once the vote extension is full, remaining txs stay pending
+				if numBids+txBids > MaxVoteExtBids {
+					h.logger.Info(fmt.Sprintf("Vote extension bid limit reached : %v", MaxVoteExtBids))
+					break collect
+				}
 
-			//// Remove tx from app side mempool
-			//err = h.mempool.Remove(tmptx)
-			if err != nil {
-				h.logger.Info(fmt.Sprintf("Unable to update mempool tx: %v", err))
-			}
+				// Report the signed tx rather than its bids, so a proposer that never
+				// received it can still include it
+				bz, err := h.txConfig.TxEncoder()(tmptx)
+				if err != nil {
+					h.logger.Error(fmt.Sprintf("Error encoding VE tx : %v", err))
+					continue
+				}
+
+				// Skip txs that would push the vote extension over the limit, every
+				// validator would reject it. A smaller tx may still fit.
+				if size+voteExtTxSize(bz) > limit {
+					h.logger.Info(fmt.Sprintf("Vote extension size limit reached : %v", limit))
+					continue
+				}
 
-			itr = itr.Next()
+				// The tx stays pending, BidPromoter promotes it once the committed
+				// vote extensions show the threshold saw its bids
+				voteExtTxs = append(voteExtTxs, bz)
+				numBids += txBids
+				size += voteExtTxSize(bz)
+			}
 		}
 
 		// Create vote extension
 		voteExt := AppVoteExtension{
 			Height: req.Height,
-			Bids:   voteExtBids,
+			Txs:    voteExtTxs,
 		}
 
 		// Encode Vote Extension
-		bz, err := json.Marshal(voteExt)
+		bz, err := voteExt.Marshal()
 		if err != nil {
 			return nil, fmt.Errorf("Error marshalling VE: %w", err)
 		}
@@ -75,3 +196,111 @@ func (h *VoteExtHandler) ExtendVoteHandler() sdk.ExtendVoteHandler {
 		return &abci.ResponseExtendVote{VoteExtension: bz}, nil
 	}
 }
+
+func (h *VoteExtHandler) VerifyVoteExtensionHandler() sdk.VerifyVoteExtensionHandler {
+	return func(ctx sdk.Context, req *abci.RequestVerifyVoteExtension) (*abci.ResponseVerifyVoteExtension, error) {
+		h.logger.Info(fmt.Sprintf("Verifying vote extension from %X at block height : %v", req.ValidatorAddress, req.Height))
+
+		if !VoteExtensionsEnabled(ctx, req.Height) {
+			if len(req.VoteExtension) > 0 {
+				h.logger.Error(fmt.Sprintf("❌ :: Rejecting vote extension from %X before enable height", req.ValidatorAddress))
+				return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_REJECT}, nil
+			}
+			return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_ACCEPT}, nil
+		}
+
+		// Validators with nothing to report may submit an empty extension
+		if len(req.VoteExtension) == 0 {
+			return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_ACCEPT}, nil
+		}
+
+		limit, err := VoteExtLimit(ctx, h.staking)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("❌ :: Unable to size vote extension from %X : %v", req.ValidatorAddress, err))
+			return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_REJECT}, nil
+		}
+
+		allowLegacy := LegacyVoteExtensionsAllowed(ctx, h.paramSpace, req.Height)
+		if err := h.validateVoteExtension(req.VoteExtension, req.Height, limit, allowLegacy); err != nil {
+			h.logger.Error(fmt.Sprintf("❌ :: Rejecting vote extension from %X : %v", req.ValidatorAddress, err))
+			return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_REJECT}, nil
+		}
+
+		return &abci.ResponseVerifyVoteExtension{Status: abci.ResponseVerifyVoteExtension_ACCEPT}, nil
+	}
+}
+
+func (h *VoteExtHandler) validateVoteExtension(bz []byte, height int64, limit int, allowLegacy bool) error {
+	if len(bz) > limit {
+		return fmt.Errorf("vote extension size %d exceeds limit %d", len(bz), limit)
+	}
+
+	ve, err := UnmarshalVoteExtension(bz)
+	if err != nil {
+		return err
+	}
+	if ve.Version == LegacyJSONVersion && !allowLegacy {
+		return fmt.Errorf("legacy vote extension outside the migration window")
+	}
+
+	if ve.Height != height {
+		return fmt.Errorf("vote extension height %d does not match request height %d", ve.Height, height)
+	}
+
+	numBids := 0
+	for i, txBytes := range ve.Txs {
+		tx, err := h.txConfig.TxDecoder()(txBytes)
+		if err != nil {
+			return fmt.Errorf("unable to decode tx %d: %w", i, err)
+		}
+		txBids := 0
+		for _, msg := range tx.GetMsgs() {
+			bid, ok := msg.(*nstypes.MsgBid)
+			if !ok {
+				continue
+			}
+			if err := validateBid(bid); err != nil {
+				return fmt.Errorf("invalid bid in tx %d: %w", i, err)
+			}
+			txBids++
+		}
+		if txBids == 0 {
+			return fmt.Errorf("tx %d carries no bids", i)
+		}
+		numBids += txBids
+	}
+
+	// Legacy vote extensions carry bare bids
+	for i, b := range ve.Bids {
+		var bid nstypes.MsgBid
+		if err := h.cdc.Unmarshal(b, &bid); err != nil {
+			return fmt.Errorf("unable to decode bid %d: %w", i, err)
+		}
+		if err := validateBid(&bid); err != nil {
+			return fmt.Errorf("invalid bid %d: %w", i, err)
+		}
+		numBids++
+	}
+
+	if numBids > MaxVoteExtBids {
+		return fmt.Errorf("vote extension contains %d bids, limit is %d", numBids, MaxVoteExtBids)
+	}
+
+	return nil
+}
+
+func validateBid(bid *nstypes.MsgBid) error {
+	if len(bid.Name) == 0 {
+		return fmt.Errorf("bid name must be set")
+	}
+	if _, err := sdk.AccAddressFromBech32(bid.Owner); err != nil {
+		return fmt.Errorf("invalid owner address: %w", err)
+	}
+	if _, err := sdk.AccAddressFromBech32(bid.ResolveAddress); err != nil {
+		return fmt.Errorf("invalid resolve address: %w", err)
+	}
+	if bid.Amount.Empty() || !bid.Amount.IsValid() {
+		return fmt.Errorf("bid amount must be positive: %v", bid.Amount)
+	}
+	return nil
+}
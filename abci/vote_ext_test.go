@@ -1 +1,315 @@
 package abci
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	storetypes "cosmossdk.io/store/types"
+	"encoding/json"
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/testutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	"github.com/fatal-fruit/cosmapp/testutils"
+	nstypes "github.com/fatal-fruit/ns/types"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// maxValidators is the staking MaxValidators param
+type maxValidators uint32
+
+func (m maxValidators) MaxValidators(_ context.Context) (uint32, error) { return uint32(m), nil }
+
+func TestVoteExtLimit(t *testing.T) {
+	withMaxBytes := func(maxBytes int64) sdk.Context {
+		return sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+			Block: &cmtproto.BlockParams{MaxBytes: maxBytes},
+		})
+	}
+
+	tests := []struct {
+		name     string
+		ctx      sdk.Context
+		maxVals  uint32
+		expected int
+	}{
+		{"no block params", sdk.Context{}, 100, MaxVoteExtSize},
+		{"few validators", withMaxBytes(22020096), 4, MaxVoteExtSize},
+		{"default block, many validators", withMaxBytes(22020096), 100, 110100},
+		{"unbounded block", withMaxBytes(-1), 100, MaxVoteExtSize},
+		{"no max validators", withMaxBytes(1024), 0, MaxVoteExtSize},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, err := VoteExtLimit(tc.ctx, maxValidators(tc.maxVals))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+func TestVerifyVoteExtension(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	logger := log.NewTestLogger(t)
+	key := storetypes.NewKVStoreKey(paramstypes.StoreKey)
+	tkey := storetypes.NewTransientStoreKey(paramstypes.TStoreKey)
+	ps := paramstypes.NewSubspace(encCfg.Marshaler, codec.NewLegacyAmino(), key, tkey, ParamsSubspace).
+		WithKeyTable(ParamKeyTable())
+	handler := NewVoteExtensionHandler(logger, mempool.NewThresholdMempool(logger, txConfig.TxEncoder()), encCfg.Marshaler, txConfig, maxValidators(100), ps)
+	verify := handler.VerifyVoteExtensionHandler()
+
+	validBid := *newBid("bob.cosmos", testBidOwner)
+	invalidAddrBid := validBid
+	invalidAddrBid.Owner = "bob"
+	zeroBid := validBid
+	zeroBid.Amount = sdk.Coins{}
+
+	marshalBid := func(b nstypes.MsgBid) []byte {
+		_, bz := buildTx(t, txConfig, nil, []sdk.Msg{&b})
+		return bz
+	}
+
+	bids := make([]sdk.Msg, MaxVoteExtBids+1)
+	for i := range bids {
+		bid := validBid
+		bids[i] = &bid
+	}
+	_, maxBidsBz := buildTx(t, txConfig, nil, bids[:MaxVoteExtBids])
+	tooManyBids := [][]byte{maxBidsBz, marshalBid(validBid)}
+
+	_, sendBz := newSendTx(t, txConfig)
+	bidBz, err := encCfg.Marshaler.Marshal(&validBid)
+	require.NoError(t, err)
+	legacy, err := json.Marshal(legacyJSON{Height: 3, Bids: [][]byte{bidBz}})
+	require.NoError(t, err)
+	lateLegacy, err := json.Marshal(legacyJSON{Height: 4, Bids: [][]byte{bidBz}})
+	require.NoError(t, err)
+
+	ctx := testutil.DefaultContext(key, tkey).WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 3},
+	})
+	// Legacy vote extensions are accepted up to height 3
+	ps.Set(ctx, KeyLegacyVoteExtHeight, int64(3))
+
+	tests := []struct {
+		name   string
+		height int64
+		ve     []byte
+		status abci.ResponseVerifyVoteExtension_VerifyStatus
+	}{
+		{"empty extension", 3, nil, abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"empty extension before enable height", 2, nil, abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"extension before enable height", 2, newVoteExt(t, 2, marshalBid(validBid)), abci.ResponseVerifyVoteExtension_REJECT},
+		{"valid bid", 3, newVoteExt(t, 3, marshalBid(validBid)), abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"no bids", 3, newVoteExt(t, 3), abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"garbage", 3, []byte("not a vote extension"), abci.ResponseVerifyVoteExtension_REJECT},
+		{"wrong height", 3, newVoteExt(t, 2, marshalBid(validBid)), abci.ResponseVerifyVoteExtension_REJECT},
+		{"undecodable tx", 3, newVoteExt(t, 3, []byte{0xff, 0xff}), abci.ResponseVerifyVoteExtension_REJECT},
+		{"tx without bids", 3, newVoteExt(t, 3, sendBz), abci.ResponseVerifyVoteExtension_REJECT},
+		{"invalid address", 3, newVoteExt(t, 3, marshalBid(invalidAddrBid)), abci.ResponseVerifyVoteExtension_REJECT},
+		{"zero amount", 3, newVoteExt(t, 3, marshalBid(zeroBid)), abci.ResponseVerifyVoteExtension_REJECT},
+		{"max bids", 3, newVoteExt(t, 3, tooManyBids[0]), abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"too many bids", 3, newVoteExt(t, 3, tooManyBids...), abci.ResponseVerifyVoteExtension_REJECT},
+		{"legacy bids", 3, legacy, abci.ResponseVerifyVoteExtension_ACCEPT},
+		{"legacy bids after migration window", 4, lateLegacy, abci.ResponseVerifyVoteExtension_REJECT},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := verify(ctx, &abci.RequestVerifyVoteExtension{
+				Height:        tc.height,
+				VoteExtension: tc.ve,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.status, resp.Status)
+		})
+	}
+}
+
+func TestVoteExtensionsEnabled(t *testing.T) {
+	disabled := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{})
+	require.False(t, VoteExtensionsEnabled(disabled, 10))
+	require.False(t, SpecialTxExpected(disabled, 10))
+
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 5},
+	})
+	require.False(t, VoteExtensionsEnabled(ctx, 4))
+	require.True(t, VoteExtensionsEnabled(ctx, 5))
+	require.False(t, SpecialTxExpected(ctx, 5))
+	require.True(t, SpecialTxExpected(ctx, 6))
+}
+
+func TestExtendVoteLeavesTxsPending(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	handler := NewVoteExtensionHandler(logger, mp, encCfg.Marshaler, txConfig, maxValidators(100), paramstypes.Subspace{})
+
+	tx, txBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "bob.cosmos")
+	require.NoError(t, mp.Insert(context.Background(), tx))
+
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	resp, err := handler.ExtendVoteHandler()(ctx, &abci.RequestExtendVote{Height: 2})
+	require.NoError(t, err)
+
+	// The signed tx is reported, not just its bid
+	ve, err := UnmarshalVoteExtension(resp.VoteExtension)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{txBz}, ve.Txs)
+
+	// Only the committed vote extensions promote a tx, reporting it does not
+	require.Nil(t, mp.Select(context.Background(), nil))
+	require.NotNil(t, mp.SelectPending(context.Background(), nil))
+}
+
+func TestExtendVoteSizeLimit(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	handler := NewVoteExtensionHandler(logger, mp, encCfg.Marshaler, txConfig, maxValidators(100), paramstypes.Subspace{})
+
+	// Bid txs padded so only two of the three large ones fit
+	insertBid := func(name string, memo int) []byte {
+		tx, bz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), name, withMemo(strings.Repeat("x", memo)))
+		require.NoError(t, mp.Insert(context.Background(), tx))
+		return bz
+	}
+	large := MaxVoteExtSize/2 - 1024
+	insertBid("alice.cosmos", large)
+	insertBid("bob.cosmos", large)
+	insertBid("carol.cosmos", large)
+	small := insertBid("dave.cosmos", 0)
+
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	resp, err := handler.ExtendVoteHandler()(ctx, &abci.RequestExtendVote{Height: 2})
+	require.NoError(t, err)
+
+	// The extension stays just under the limit, the tx that does not fit is
+	// skipped and a smaller one after it still reported
+	require.LessOrEqual(t, len(resp.VoteExtension), MaxVoteExtSize)
+	require.Greater(t, len(resp.VoteExtension), MaxVoteExtSize-4096)
+	ve, err := UnmarshalVoteExtension(resp.VoteExtension)
+	require.NoError(t, err)
+	require.Len(t, ve.Txs, 3)
+	require.Contains(t, ve.Txs, small)
+	require.Equal(t, len(resp.VoteExtension), ve.Size())
+
+	verify, err := handler.VerifyVoteExtensionHandler()(ctx, &abci.RequestVerifyVoteExtension{
+		Height:        2,
+		VoteExtension: resp.VoteExtension,
+	})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseVerifyVoteExtension_ACCEPT, verify.Status)
+
+	// In a block too smallBlock for 100 full extensions, extensions are held to a
+	// share of it and larger ones are rejected
+	smallBlock := ctx.WithConsensusParams(cmtproto.ConsensusParams{
+		Block: &cmtproto.BlockParams{MaxBytes: 100 * MaxVoteExtSize},
+		Abci:  &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	limit, err := VoteExtLimit(smallBlock, maxValidators(100))
+	require.NoError(t, err)
+	require.Equal(t, MaxVoteExtSize/2, limit)
+
+	verify, err = handler.VerifyVoteExtensionHandler()(smallBlock, &abci.RequestVerifyVoteExtension{
+		Height:        2,
+		VoteExtension: resp.VoteExtension,
+	})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseVerifyVoteExtension_REJECT, verify.Status)
+
+	resp, err = handler.ExtendVoteHandler()(smallBlock, &abci.RequestExtendVote{Height: 2})
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(resp.VoteExtension), limit)
+	ve, err = UnmarshalVoteExtension(resp.VoteExtension)
+	require.NoError(t, err)
+	require.Len(t, ve.Txs, 2)
+
+	verify, err = handler.VerifyVoteExtensionHandler()(smallBlock, &abci.RequestVerifyVoteExtension{
+		Height:        2,
+		VoteExtension: resp.VoteExtension,
+	})
+	require.NoError(t, err)
+	require.Equal(t, abci.ResponseVerifyVoteExtension_ACCEPT, verify.Status)
+}
+
+func TestExtendVoteSkipsCommittedBids(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	handler := NewVoteExtensionHandler(logger, mp, encCfg.Marshaler, txConfig, maxValidators(100), paramstypes.Subspace{})
+
+	// The block commits a bid, the mempool holds another tx carrying it
+	bob := secp256k1.GenPrivKey().PubKey()
+	_, committedBz := newBidTx(t, txConfig, bob, "bob.cosmos", withMemo("in block"))
+	copied, _ := newBidTx(t, txConfig, bob, "bob.cosmos", withMemo("fee bump"))
+	other, otherBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "alice.cosmos")
+	require.NoError(t, mp.Insert(context.Background(), copied))
+	require.NoError(t, mp.Insert(context.Background(), other))
+
+	special, err := SpecialTransaction{Height: 2}.Marshal()
+	require.NoError(t, err)
+
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	resp, err := handler.ExtendVoteHandler()(ctx, &abci.RequestExtendVote{
+		Height: 3,
+		Txs:    [][]byte{special, committedBz},
+	})
+	require.NoError(t, err)
+
+	// The special tx does not hide the block's bids, the copy is not reported
+	ve, err := UnmarshalVoteExtension(resp.VoteExtension)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{otherBz}, ve.Txs)
+}
+
+func TestExtendVoteSkipsBlockTxs(t *testing.T) {
+	encCfg := testutils.MakeTestEncodingConfig()
+	logger := log.NewTestLogger(t)
+	txConfig := newSignedTxConfig(encCfg.TxConfig)
+	mp := mempool.NewThresholdMempool(logger, txConfig.TxEncoder())
+	handler := NewVoteExtensionHandler(logger, mp, encCfg.Marshaler, txConfig, maxValidators(100), paramstypes.Subspace{})
+
+	// The block carries a pending tx, a ready one and one the mempool never saw
+	pending, pendingBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "alice.cosmos")
+	ready, readyBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "bob.cosmos")
+	left, leftBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "carol.cosmos")
+	_, unknownBz := newBidTx(t, txConfig, secp256k1.GenPrivKey().PubKey(), "dave.cosmos")
+	for _, tx := range []sdk.Tx{pending, ready, left} {
+		require.NoError(t, mp.Insert(context.Background(), tx))
+	}
+	require.NoError(t, mp.Update(context.Background(), ready))
+	require.True(t, mp.Contains(mempool.TxHash(pendingBz)))
+	require.True(t, mp.Contains(mempool.TxHash(readyBz)))
+	require.False(t, mp.Contains(mempool.TxHash(unknownBz)))
+
+	ctx := sdk.Context{}.WithConsensusParams(cmtproto.ConsensusParams{
+		Abci: &cmtproto.ABCIParams{VoteExtensionsEnableHeight: 1},
+	})
+	resp, err := handler.ExtendVoteHandler()(ctx, &abci.RequestExtendVote{
+		Height: 2,
+		Txs:    [][]byte{pendingBz, readyBz, unknownBz},
+	})
+	require.NoError(t, err)
+
+	ve, err := UnmarshalVoteExtension(resp.VoteExtension)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{leftBz}, ve.Txs)
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"cosmossdk.io/math"
 	storetypes "cosmossdk.io/store/types"
 	"cosmossdk.io/x/tx/signing"
 	"cosmossdk.io/x/upgrade"
@@ -9,7 +10,9 @@ import (
 	"fmt"
 	"github.com/cosmos/cosmos-sdk/client/flags"
 	abci2 "github.com/fatal-fruit/cosmapp/abci"
+	abciv1 "github.com/fatal-fruit/cosmapp/abci/types"
 	mempool2 "github.com/fatal-fruit/cosmapp/mempool"
+	mempoolv1 "github.com/fatal-fruit/cosmapp/mempool/types"
 	"github.com/fatal-fruit/cosmapp/provider"
 	"github.com/spf13/cast"
 	"io"
@@ -122,6 +125,11 @@ type App struct {
 	ConsensusParamsKeeper consensusparamkeeper.Keeper
 	NameserviceKeeper     nskeeper.Keeper
 
+	SpecialTxStore *abci2.SpecialTxStore
+	// mempoolQuery inspects the app side mempool, registered with the node service
+	mempoolQuery mempoolv1.QueryServer
+	mempoolWAL   *mempool2.WAL
+
 	mm           *module.Manager
 	BasicManager module.BasicManager
 
@@ -170,6 +178,7 @@ func NewApp(
 
 	std.RegisterLegacyAminoCodec(legacyAmino)
 	std.RegisterInterfaces(interfaceRegistry)
+	abciv1.RegisterInterfaces(interfaceRegistry)
 
 	/*
 		*************************
@@ -177,16 +186,108 @@ func NewApp(
 		*************************
 	*/
 
-	mempool := mempool2.NewThresholdMempool(logger)
+	priority, err := abci2.MempoolPriority(cast.ToString(appOpts.Get(apptypes.FlagMempoolPriority)), DefaultDenom)
+	if err != nil {
+		panic(err)
+	}
+	replacementBump := mempool2.DefaultReplacementBump
+	if v := cast.ToString(appOpts.Get(apptypes.FlagReplacementBump)); v != "" {
+		replacementBump, err = math.LegacyNewDecFromStr(v)
+		if err != nil || replacementBump.IsNegative() {
+			panic(fmt.Errorf("invalid %s: %q", apptypes.FlagReplacementBump, v))
+		}
+	}
+	pendingLimits, readyLimits, defaultLimits, ttl := mempool2.DefaultPoolLimits, mempool2.DefaultPoolLimits, mempool2.DefaultPoolLimits, mempool2.DefaultTTL
+	if v := appOpts.Get(apptypes.FlagPendingMaxTxs); v != nil {
+		pendingLimits.MaxTxs = cast.ToInt(v)
+	}
+	if v := appOpts.Get(apptypes.FlagPendingMaxBytes); v != nil {
+		pendingLimits.MaxBytes = cast.ToInt64(v)
+	}
+	if v := appOpts.Get(apptypes.FlagReadyMaxTxs); v != nil {
+		readyLimits.MaxTxs = cast.ToInt(v)
+	}
+	if v := appOpts.Get(apptypes.FlagReadyMaxBytes); v != nil {
+		readyLimits.MaxBytes = cast.ToInt64(v)
+	}
+	if v := appOpts.Get(apptypes.FlagDefaultMaxTxs); v != nil {
+		defaultLimits.MaxTxs = cast.ToInt(v)
+	}
+	if v := appOpts.Get(apptypes.FlagDefaultMaxBytes); v != nil {
+		defaultLimits.MaxBytes = cast.ToInt64(v)
+	}
+	if v := appOpts.Get(apptypes.FlagMempoolTTL); v != nil {
+		ttl = cast.ToInt64(v)
+	}
+	senderLimits := mempool2.DefaultSenderLimits
+	if v := appOpts.Get(apptypes.FlagSenderMaxTxs); v != nil {
+		senderLimits.MaxPendingTxs = cast.ToInt(v)
+	}
+	if v := appOpts.Get(apptypes.FlagBidsPerBlock); v != nil {
+		senderLimits.BidsPerBlock = cast.ToFloat64(v)
+	}
+	if v := appOpts.Get(apptypes.FlagBidBurst); v != nil {
+		senderLimits.BidBurst = cast.ToInt(v)
+	}
+	if senderLimits.BidsPerBlock < 0 || (senderLimits.BidsPerBlock > 0 && senderLimits.BidBurst < 1) {
+		panic(fmt.Errorf("invalid %s and %s: %v and %v", apptypes.FlagBidsPerBlock, apptypes.FlagBidBurst, senderLimits.BidsPerBlock, senderLimits.BidBurst))
+	}
+	mempoolOpts := []mempool2.Option{
+		mempool2.WithPriority(priority),
+		mempool2.WithReplacementBump(replacementBump),
+		mempool2.WithPendingLimits(pendingLimits),
+		mempool2.WithReadyLimits(readyLimits),
+		mempool2.WithDefaultLaneLimits(defaultLimits),
+		mempool2.WithTTL(ttl),
+		mempool2.WithSenderLimits(senderLimits),
+	}
+	// Only bids wait for vote extensions unless lanes are explicitly disabled
+	lanes := true
+	if v := appOpts.Get(apptypes.FlagLanes); v != nil {
+		lanes = cast.ToBool(v)
+	}
+	if lanes {
+		mempoolOpts = append(mempoolOpts, mempool2.WithLanes(abci2.IsBidTx))
+	}
+	auctionShare := abci2.DefaultAuctionLaneShare
+	if v := cast.ToString(appOpts.Get(apptypes.FlagAuctionShare)); v != "" {
+		auctionShare, err = math.LegacyNewDecFromStr(v)
+		if err != nil || auctionShare.IsNegative() || auctionShare.GT(math.LegacyOneDec()) {
+			panic(fmt.Errorf("invalid %s: %q", apptypes.FlagAuctionShare, v))
+		}
+	}
+	// The on-chain param overrides the node's default bid threshold once it is set
+	bidThreshold := abci2.DefaultBidThreshold
+	if v := cast.ToString(appOpts.Get(apptypes.FlagBidThreshold)); v != "" {
+		bidThreshold, err = math.LegacyNewDecFromStr(v)
+		if err != nil || abci2.ValidateBidThreshold(bidThreshold) != nil {
+			panic(fmt.Errorf("invalid %s: %q", apptypes.FlagBidThreshold, v))
+		}
+	}
+	// Optionally keep the mempool across restarts, it is restored once all tx types are registered.
+	// Only a starting node owns the log, apps built for export or queries leave it alone.
+	var mempoolWAL *mempool2.WAL
+	if loadLatest && cast.ToBool(appOpts.Get(apptypes.FlagMempoolWAL)) {
+		mempoolWAL, err = mempool2.OpenWAL(filepath.Join(homePath, "data", "threshold-mempool.wal"))
+		if err != nil {
+			panic(fmt.Errorf("unable to open mempool wal: %w", err))
+		}
+		mempoolOpts = append(mempoolOpts, mempool2.WithWAL(mempoolWAL))
+	}
+	// The special transaction is executed as MsgSpecialTransaction
+	txDecoder := abci2.SpecialTxDecoder(txConfig.TxDecoder())
+	txEncoder := abci2.SpecialTxEncoder(txConfig.TxEncoder())
+
+	mempool := mempool2.NewThresholdMempool(logger, txEncoder, mempoolOpts...)
 	baseAppOptions = append(baseAppOptions, func(app *baseapp.BaseApp) {
 		app.SetMempool(mempool)
 	})
 
-	bApp := baseapp.NewBaseApp(AppName, logger, db, txConfig.TxDecoder(), baseAppOptions...)
+	bApp := baseapp.NewBaseApp(AppName, logger, db, txDecoder, baseAppOptions...)
 	bApp.SetCommitMultiStoreTracer(traceStore)
 	bApp.SetVersion(version.Version)
 	bApp.SetInterfaceRegistry(interfaceRegistry)
-	bApp.SetTxEncoder(txConfig.TxEncoder())
+	bApp.SetTxEncoder(txEncoder)
 	keys := storetypes.NewKVStoreKeys(
 		authtypes.StoreKey,
 		banktypes.StoreKey,
@@ -197,6 +298,7 @@ func NewApp(
 		upgradetypes.StoreKey,
 		consensusparamtypes.StoreKey,
 		nstypes.StoreKey,
+		abci2.StoreKey,
 	)
 
 	// register streaming services
@@ -229,31 +331,6 @@ func NewApp(
 		authtypes.NewModuleAddress(govtypes.ModuleName).String(),
 	)
 
-	/*
-		*************************
-		Configure ABCI++ Handlers
-		*************************
-	*/
-	bp := &provider.LocalTxProvider{
-		Logger: logger,
-		Codec:  app.appCodec,
-		Signer: provider.LocalSigner{
-			KeyName:    valKeyName,
-			KeyringDir: homePath,
-		},
-		TxConfig:   app.txConfig,
-		AcctKeeper: app.AccountKeeper,
-	}
-	if err := bp.Init(); err != nil {
-		panic(err)
-	}
-	voteExtHandler := abci2.NewVoteExtensionHandler(logger, mempool, appCodec)
-	prepareProposalHandler := abci2.NewPrepareProposalHandler(logger, app.txConfig, appCodec, mempool, bp, runProvider)
-	processPropHandler := abci2.ProcessProposalHandler{app.txConfig, appCodec, logger}
-	bApp.SetPrepareProposal(prepareProposalHandler.PrepareProposalHandler())
-	bApp.SetProcessProposal(processPropHandler.ProcessProposalHandler())
-	bApp.SetExtendVoteHandler(voteExtHandler.ExtendVoteHandler())
-
 	app.ParamsKeeper = initParamsKeeper(appCodec, legacyAmino, keys[paramstypes.StoreKey], tkeys[paramstypes.TStoreKey])
 
 	// set the BaseApp's parameter store
@@ -347,6 +424,44 @@ func NewApp(
 		DefaultDenom,
 	)
 
+	/*
+		*************************
+		Configure ABCI++ Handlers
+		*************************
+	*/
+	bp := &provider.LocalTxProvider{
+		Logger: logger,
+		Codec:  app.appCodec,
+		Signer: provider.LocalSigner{
+			KeyName:    valKeyName,
+			KeyringDir: homePath,
+		},
+		TxConfig:   app.txConfig,
+		AcctKeeper: app.AccountKeeper,
+	}
+	if err := bp.Init(); err != nil {
+		panic(err)
+	}
+	voteExtHandler := abci2.NewVoteExtensionHandler(logger, mempool, appCodec, app.txConfig, app.StakingKeeper, app.GetSubspace(abci2.ParamsSubspace))
+	bidPromoter := abci2.NewBidPromoter(logger, mempool, app.txConfig.TxDecoder(), appCodec, app.GetSubspace(abci2.ParamsSubspace), bidThreshold)
+	app.mempoolQuery = mempool2.NewQueryServer(mempool, bidPromoter.Observed)
+	// Required bids are only included with txs whose signatures verify
+	txVerifier := abci2.NewSigVerifier(app.AccountKeeper, app.txConfig.SignModeHandler())
+	prepareProposalHandler := abci2.NewPrepareProposalHandler(logger, app.txConfig, appCodec, mempool, bp, runProvider, app.StakingKeeper, txVerifier, app.GetSubspace(abci2.ParamsSubspace), bidThreshold, bidPromoter, auctionShare)
+	processPropHandler := abci2.NewProcessProposalHandler(logger, app.txConfig, appCodec, app.StakingKeeper, txVerifier, app.GetSubspace(abci2.ParamsSubspace), bidThreshold)
+	bApp.SetPrepareProposal(prepareProposalHandler.PrepareProposalHandler())
+	bApp.SetProcessProposal(processPropHandler.ProcessProposalHandler())
+	bApp.SetExtendVoteHandler(voteExtHandler.ExtendVoteHandler())
+	bApp.SetVerifyVoteExtensionHandler(voteExtHandler.VerifyVoteExtensionHandler())
+
+	app.SpecialTxStore = abci2.NewSpecialTxStore(logger, runtime.NewKVStoreService(keys[abci2.StoreKey]), app.txConfig.TxDecoder(), appCodec, app.GetSubspace(abci2.ParamsSubspace), bidThreshold, bidPromoter)
+	bApp.SetPreBlocker(app.SpecialTxStore.PreBlocker())
+	abciv1.RegisterMsgServer(app.MsgServiceRouter(), app.SpecialTxStore)
+	abciv1.RegisterQueryServer(app.GRPCQueryRouter(), app.SpecialTxStore)
+
+	recheckHandler := abci2.NewRecheckHandler(logger, mempool, app.AccountKeeper, app.NameserviceKeeper.NameMapping)
+	bApp.SetPrepareCheckStater(recheckHandler.PrepareCheckStater())
+
 	app.mm = module.NewManager(
 		genutil.NewAppModule(
 			app.AccountKeeper, app.StakingKeeper, app,
@@ -412,7 +527,7 @@ func NewApp(
 		app.MsgServiceRouter(),
 		app.GRPCQueryRouter(),
 	)
-	err := app.mm.RegisterServices(app.configurator)
+	err = app.mm.RegisterServices(app.configurator)
 	if err != nil {
 		panic(err)
 	}
@@ -431,7 +546,8 @@ func NewApp(
 	app.MountKVStores(keys)
 	app.MountTransientStores(tkeys)
 
-	// <Upgrade handler setup here>
+	app.setUpgradeHandlers()
+
 	// initialize BaseApp
 	app.SetInitChainer(app.InitChainer)
 	app.SetBeginBlocker(app.BeginBlocker)
@@ -455,15 +571,39 @@ func NewApp(
 		}
 	}
 
+	app.mempoolWAL = mempoolWAL
+
 	if loadLatest {
 		if err := app.LoadLatestVersion(); err != nil {
 			panic(fmt.Errorf("error loading last version: %w", err))
 		}
+
+		restored, err := mempool.Restore(txConfig.TxDecoder())
+		if err != nil {
+			panic(fmt.Errorf("unable to restore mempool: %w", err))
+		}
+
+		// Drop restored txs that the committed state no longer allows
+		if restored > 0 {
+			evicted := mempool.Recheck(app.NewContext(true), recheckHandler.CheckTx)
+			logger.Info(fmt.Sprintf("🛠️ :: Restored %v transactions to mempool, %v failed recheck", restored, evicted))
+		}
 	}
 
 	return app
 }
 
+// Close closes the mempool WAL, if any, after the BaseApp
+func (app *App) Close() error {
+	err := app.BaseApp.Close()
+	if app.mempoolWAL != nil {
+		if walErr := app.mempoolWAL.Close(); walErr != nil && err == nil {
+			err = walErr
+		}
+	}
+	return err
+}
+
 func (app *App) Name() string { return app.BaseApp.Name() }
 
 // BeginBlocker application updates every begin block
@@ -480,16 +620,16 @@ func (app *App) EndBlocker(ctx sdk.Context) (sdk.EndBlock, error) {
 func (app *App) InitChainer(ctx sdk.Context, req *abci.RequestInitChain) (*abci.ResponseInitChain, error) {
 	var genesisState GenesisState
 
-	// Enable VE
-	//req.ConsensusParams = &cmtproto.ConsensusParams{
-	//	Abci: &cmtproto.ABCIParams{
-	//		VoteExtensionsEnableHeight: 2,
-	//	},
-	//}
+	// Vote extensions are enabled through the genesis consensus params
+	// (consensus.params.abci.vote_extensions_enable_height) or a later
+	// governance update of the consensus params, never hard-coded here.
 
 	if err := json.Unmarshal(req.AppStateBytes, &genesisState); err != nil {
 		panic(err)
 	}
+	if err := abci2.InitGenesis(ctx, app.GetSubspace(abci2.ParamsSubspace), genesisState[abci2.ParamsSubspace]); err != nil {
+		panic(err)
+	}
 	app.UpgradeKeeper.SetModuleVersionMap(ctx, app.mm.GetVersionMap())
 	return app.mm.InitGenesis(ctx, app.appCodec, genesisState)
 }
@@ -613,6 +753,7 @@ func (app *App) RegisterTendermintService(clientCtx client.Context) {
 
 func (app *App) RegisterNodeService(clientCtx client.Context, cfg config.Config) {
 	nodeservice.RegisterNodeService(clientCtx, app.GRPCQueryRouter(), cfg)
+	mempoolv1.RegisterQueryServer(app.GRPCQueryRouter(), app.mempoolQuery)
 }
 
 func (app *App) OnTxSucceeded(_ sdk.Context, _, _ string, _ []byte, _ []byte) {
@@ -635,6 +776,8 @@ func (ao EmptyAppOptions) Get(_ string) interface{} {
 func initParamsKeeper(appCodec codec.BinaryCodec, legacyAmino *codec.LegacyAmino, key, tkey storetypes.StoreKey) paramskeeper.Keeper {
 	paramsKeeper := paramskeeper.NewKeeper(appCodec, legacyAmino, key, tkey)
 
+	paramsKeeper.Subspace(abci2.ParamsSubspace).WithKeyTable(abci2.ParamKeyTable())
+
 	// TODO: ibc module subspaces can be removed after migration of params
 	// https://github.com/cosmos/ibc-go/issues/2010
 
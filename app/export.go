@@ -10,6 +10,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	abci2 "github.com/fatal-fruit/cosmapp/abci"
 )
 
 // ExportAppStateAndValidators exports the state of the application for a genesis
@@ -31,6 +32,13 @@ func (app *App) ExportAppStateAndValidators(
 	}
 
 	genState, err := app.mm.ExportGenesisForModules(ctx, app.appCodec, modulesToExport)
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
+	genState[abci2.ParamsSubspace], err = abci2.ExportGenesis(ctx, app.GetSubspace(abci2.ParamsSubspace))
+	if err != nil {
+		return servertypes.ExportedApp{}, err
+	}
 	appState, err := json.MarshalIndent(genState, "", "  ")
 	if err != nil {
 		return servertypes.ExportedApp{}, err
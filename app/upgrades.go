@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	storetypes "cosmossdk.io/store/types"
+	upgradetypes "cosmossdk.io/x/upgrade/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/module"
+	abci2 "github.com/fatal-fruit/cosmapp/abci"
+)
+
+// SpecialTxUpgradeName is the upgrade adding the special transaction store
+const SpecialTxUpgradeName = "specialtx"
+
+// setUpgradeHandlers registers the upgrade handlers and, when the node is
+// restarted at a planned upgrade, the store changes it makes. It must run
+// before the latest version is loaded.
+func (app *App) setUpgradeHandlers() {
+	app.UpgradeKeeper.SetUpgradeHandler(SpecialTxUpgradeName, func(ctx context.Context, _ upgradetypes.Plan, fromVM module.VersionMap) (module.VersionMap, error) {
+		// Vote extensions from the last blocks of the JSON release are still accepted
+		sdkCtx := sdk.UnwrapSDKContext(ctx)
+		app.GetSubspace(abci2.ParamsSubspace).Set(sdkCtx, abci2.KeyLegacyVoteExtHeight, sdkCtx.BlockHeight()+abci2.LegacyVoteExtWindow)
+		return app.mm.RunMigrations(ctx, app.configurator, fromVM)
+	})
+
+	upgradeInfo, err := app.UpgradeKeeper.ReadUpgradeInfoFromDisk()
+	if err != nil {
+		panic(fmt.Errorf("unable to read upgrade info from disk: %w", err))
+	}
+	if app.UpgradeKeeper.IsSkipHeight(upgradeInfo.Height) {
+		return
+	}
+
+	if upgradeInfo.Name == SpecialTxUpgradeName {
+		storeUpgrades := storetypes.StoreUpgrades{
+			Added: []string{abci2.StoreKey},
+		}
+		app.SetStoreLoader(upgradetypes.UpgradeStoreLoader(upgradeInfo.Height, &storeUpgrades))
+	}
+}
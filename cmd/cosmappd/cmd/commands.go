@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"errors"
+	"github.com/fatal-fruit/cosmapp/abci"
+	abcicli "github.com/fatal-fruit/cosmapp/abci/client/cli"
+	"github.com/fatal-fruit/cosmapp/mempool"
+	mempoolcli "github.com/fatal-fruit/cosmapp/mempool/client/cli"
 	"github.com/fatal-fruit/cosmapp/testutils"
 	"github.com/fatal-fruit/cosmapp/types"
 	"io"
@@ -40,8 +44,33 @@ func initTendermintConfig() *tmcfg.Config {
 }
 
 func initAppConfig() (string, interface{}) {
+	type ThresholdMempoolConfig struct {
+		Priority        string  `mapstructure:"priority"`
+		ReplacementBump string  `mapstructure:"replacement-bump"`
+		PendingMaxTxs   int     `mapstructure:"pending-max-txs"`
+		PendingMaxBytes int64   `mapstructure:"pending-max-bytes"`
+		ReadyMaxTxs     int     `mapstructure:"ready-max-txs"`
+		ReadyMaxBytes   int64   `mapstructure:"ready-max-bytes"`
+		TTLBlocks       int64   `mapstructure:"ttl-blocks"`
+		Lanes           bool    `mapstructure:"lanes"`
+		AuctionShare    string  `mapstructure:"auction-lane-share"`
+		DefaultMaxTxs   int     `mapstructure:"default-max-txs"`
+		DefaultMaxBytes int64   `mapstructure:"default-max-bytes"`
+		WAL             bool    `mapstructure:"wal"`
+		SenderMaxTxs    int     `mapstructure:"sender-max-pending-txs"`
+		BidsPerBlock    float64 `mapstructure:"bids-per-block"`
+		BidBurst        int     `mapstructure:"bid-burst"`
+	}
+
+	type VoteExtensionsConfig struct {
+		BidThreshold string `mapstructure:"bid-threshold"`
+	}
+
 	type CustomAppConfig struct {
 		serverconfig.Config
+
+		ThresholdMempool ThresholdMempoolConfig `mapstructure:"threshold-mempool"`
+		VoteExtensions   VoteExtensionsConfig   `mapstructure:"vote-extensions"`
 	}
 
 	srvCfg := serverconfig.DefaultConfig()
@@ -50,9 +79,102 @@ func initAppConfig() (string, interface{}) {
 
 	customAppConfig := CustomAppConfig{
 		Config: *srvCfg,
+		ThresholdMempool: ThresholdMempoolConfig{
+			Priority:        abci.PriorityFee,
+			ReplacementBump: mempool.DefaultReplacementBump.String(),
+			PendingMaxTxs:   mempool.DefaultPoolLimits.MaxTxs,
+			PendingMaxBytes: mempool.DefaultPoolLimits.MaxBytes,
+			ReadyMaxTxs:     mempool.DefaultPoolLimits.MaxTxs,
+			ReadyMaxBytes:   mempool.DefaultPoolLimits.MaxBytes,
+			TTLBlocks:       mempool.DefaultTTL,
+			Lanes:           true,
+			AuctionShare:    abci.DefaultAuctionLaneShare.String(),
+			DefaultMaxTxs:   mempool.DefaultPoolLimits.MaxTxs,
+			DefaultMaxBytes: mempool.DefaultPoolLimits.MaxBytes,
+			SenderMaxTxs:    mempool.DefaultSenderLimits.MaxPendingTxs,
+			BidsPerBlock:    mempool.DefaultSenderLimits.BidsPerBlock,
+			BidBurst:        mempool.DefaultSenderLimits.BidBurst,
+		},
+		VoteExtensions: VoteExtensionsConfig{
+			BidThreshold: abci.DefaultBidThreshold.String(),
+		},
 	}
 
-	defaultAppTemplate := serverconfig.DefaultConfigTemplate
+	defaultAppTemplate := serverconfig.DefaultConfigTemplate + `
+###############################################################################
+###                           Threshold Mempool                             ###
+###############################################################################
+
+[threshold-mempool]
+
+# Order in which ready transactions are proposed. Each sender's transactions
+# always stay in sequence order.
+#  - "fee": highest gas price first
+#  - "bid": highest name bid first, other transactions by gas price
+priority = "{{ .ThresholdMempool.Priority }}"
+
+# Minimum gas price increase, as a fraction of the existing transaction's gas
+# price, required for a transaction to replace one with the same sender and
+# sequence.
+replacement-bump = "{{ .ThresholdMempool.ReplacementBump }}"
+
+# Bounds on the pending pool (transactions not yet reported in a vote
+# extension) and the ready pool (transactions reported and awaiting inclusion).
+# When a pool is full the lowest priority transaction is evicted to make room.
+# 0 disables a bound.
+pending-max-txs = {{ .ThresholdMempool.PendingMaxTxs }}
+pending-max-bytes = {{ .ThresholdMempool.PendingMaxBytes }}
+ready-max-txs = {{ .ThresholdMempool.ReadyMaxTxs }}
+ready-max-bytes = {{ .ThresholdMempool.ReadyMaxBytes }}
+
+# Number of blocks a transaction may wait in either pool before it is dropped.
+# 0 keeps transactions until they are included or fail recheck.
+ttl-blocks = {{ .ThresholdMempool.TTLBlocks }}
+
+# Route only name bids through the vote extension gated auction lane. Other
+# transactions go to a default lane and can be proposed as soon as they arrive.
+# When false every transaction waits in the pending pool until the next
+# committed vote extensions are applied, and bids until they show enough
+# validators saw them.
+lanes = {{ .ThresholdMempool.Lanes }}
+
+# Fraction of each block's transaction bytes reserved for the auction lane.
+# The default lane fills the rest, including space the auction lane leaves
+# unused.
+auction-lane-share = "{{ .ThresholdMempool.AuctionShare }}"
+
+# Bounds on the default lane's pool, as for the pools above.
+default-max-txs = {{ .ThresholdMempool.DefaultMaxTxs }}
+default-max-bytes = {{ .ThresholdMempool.DefaultMaxBytes }}
+
+# Log mempool changes to data/threshold-mempool.wal under the node home so the
+# mempool, including which bids were already promoted, survives a restart.
+# Restored transactions are rechecked against the latest state on startup.
+wal = {{ .ThresholdMempool.WAL }}
+
+# Limits on what a single account may add to the auction lane, applied to
+# every signer of a transaction. Transactions over a limit are rejected in
+# CheckTx.
+#  - sender-max-pending-txs: transactions waiting for vote extensions, 0 disables it
+#  - bids-per-block: allowance refilled every block, 0 disables the rate limit
+#  - bid-burst: most the allowance can build up to, at least 1
+sender-max-pending-txs = {{ .ThresholdMempool.SenderMaxTxs }}
+bids-per-block = {{ .ThresholdMempool.BidsPerBlock }}
+bid-burst = {{ .ThresholdMempool.BidBurst }}
+
+###############################################################################
+###                           Vote Extensions                               ###
+###############################################################################
+
+[vote-extensions]
+
+# Fraction of voting power that must have seen a bid in the previous block's
+# vote extensions before it can be included in a proposal. Once the "voteext"
+# BidThreshold param is set on-chain, at genesis or through governance, it
+# takes precedence over this node default, which validators must agree on
+# until then.
+bid-threshold = "{{ .VoteExtensions.BidThreshold }}"
+`
 
 	return defaultAppTemplate, customAppConfig
 }
@@ -113,6 +235,8 @@ func queryCommand() *cobra.Command {
 		server.QueryBlocksCmd(),
 		authcmd.QueryTxCmd(),
 		server.QueryBlockResultsCmd(),
+		mempoolcli.GetQueryCmd(),
+		abcicli.GetQueryCmd(),
 	)
 
 	return cmd
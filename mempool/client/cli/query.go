@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	mempoolv1 "github.com/fatal-fruit/cosmapp/mempool/types"
+	"github.com/spf13/cobra"
+)
+
+// GetQueryCmd returns the commands inspecting the node's threshold mempool
+func GetQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                        "mempool",
+		Short:                      "Inspect the node's threshold mempool",
+		DisableFlagParsing:         true,
+		SuggestionsMinimumDistance: 2,
+		RunE:                       client.ValidateCmd,
+	}
+
+	cmd.AddCommand(
+		GetCmdQueryPending(),
+		GetCmdQueryReady(),
+		GetCmdQueryStats(),
+	)
+
+	return cmd
+}
+
+func GetCmdQueryPending() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pending",
+		Short: "List txs waiting for vote extension evidence",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := mempoolv1.NewQueryClient(clientCtx).Pending(cmd.Context(), &mempoolv1.QueryPendingRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
+
+func GetCmdQueryReady() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ready",
+		Short: "List txs that can be proposed, auction lane first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := mempoolv1.NewQueryClient(clientCtx).Ready(cmd.Context(), &mempoolv1.QueryReadyRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
+
+func GetCmdQueryStats() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show mempool pool sizes and eviction counts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			clientCtx, err := client.GetClientQueryContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			res, err := mempoolv1.NewQueryClient(clientCtx).Stats(cmd.Context(), &mempoolv1.QueryStatsRequest{})
+			if err != nil {
+				return err
+			}
+
+			return clientCtx.PrintProto(res)
+		},
+	}
+
+	flags.AddQueryFlagsToCmd(cmd)
+	return cmd
+}
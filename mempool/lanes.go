@@ -0,0 +1,59 @@
+package mempool
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MatchFunc reports whether a tx belongs to a lane
+type MatchFunc func(tx sdk.Tx) bool
+
+// WithLanes splits the mempool into two lanes. Txs matched by threshold go
+// through the threshold lane: they wait in the pending pool until a vote
+// extension reports them and are then proposed from the ready pool. All
+// other txs enter the default lane, where they can be proposed straight away
+// with SelectDefault. Without this option every tx uses the threshold lane.
+//
+// A sender may have txs in both lanes. Each selection holds back a sender's
+// txs while one with a lower sequence waits elsewhere: Select behind the
+// pending pool and the default lane, SelectPending behind the default lane
+// and SelectDefault behind the whole threshold lane. A ready tx may still be
+// left out of a proposal, e.g. once the auction lane's share is used, so the
+// default lane waits for it to be committed.
+func WithLanes(threshold MatchFunc) Option {
+	return func(t *ThresholdMempool) {
+		t.thresholdLane = threshold
+	}
+}
+
+// holdBack drops the txs for which one of their signers has a tx with a lower
+// sequence in one of the pools of kinds. Callers must hold the lock.
+func (t *ThresholdMempool) holdBack(txs []thTx, kinds ...poolKind) []thTx {
+	lowest := make(map[string]uint64)
+	for _, kind := range kinds {
+		for _, ttx := range t.poolOf(kind).txs {
+			for _, s := range ttx.signers {
+				if seq, ok := lowest[s.address]; !ok || s.sequence < seq {
+					lowest[s.address] = s.sequence
+				}
+			}
+		}
+	}
+	if len(lowest) == 0 {
+		return txs
+	}
+
+	kept := txs[:0]
+	for _, ttx := range txs {
+		held := false
+		for _, s := range ttx.signers {
+			if seq, ok := lowest[s.address]; ok && seq < s.sequence {
+				held = true
+				break
+			}
+		}
+		if !held {
+			kept = append(kept, ttx)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,162 @@
+package mempool
+
+import (
+	"fmt"
+	"github.com/cosmos/cosmos-sdk/types/mempool"
+)
+
+var (
+	// DefaultPoolLimits bounds each pool unless overridden
+	DefaultPoolLimits = PoolLimits{MaxTxs: 5000, MaxBytes: 32 << 20}
+	// DefaultTTL is the number of blocks a tx may wait in a pool
+	DefaultTTL int64 = 100
+)
+
+// PoolLimits bounds the size of a pool. Zero values leave a dimension unbounded.
+type PoolLimits struct {
+	MaxTxs   int
+	MaxBytes int64
+}
+
+// WithPendingLimits bounds the pending pool
+func WithPendingLimits(limits PoolLimits) Option {
+	return func(t *ThresholdMempool) {
+		t.pendingLimits = limits
+	}
+}
+
+// WithReadyLimits bounds the ready pool
+func WithReadyLimits(limits PoolLimits) Option {
+	return func(t *ThresholdMempool) {
+		t.readyLimits = limits
+	}
+}
+
+// WithDefaultLaneLimits bounds the default lane
+func WithDefaultLaneLimits(limits PoolLimits) Option {
+	return func(t *ThresholdMempool) {
+		t.defaultLimits = limits
+	}
+}
+
+// WithTTL drops txs that stay in the same pool for more than blocks blocks
+func WithTTL(blocks int64) Option {
+	return func(t *ThresholdMempool) {
+		t.ttl = blocks
+	}
+}
+
+// evictionPlan returns the txs to evict from p so that incoming fits within
+// limits. A tx may only be evicted if it holds the highest sequence of each
+// of its signers, so eviction never leaves a gap in a signer's sequence, and
+// only if its priority is strictly lower than incoming's. freed names txs of
+// p that are about to be removed anyway. p is not modified.
+func evictionPlan(p *thTxs, limits PoolLimits, incoming thTx, freed []string) ([]thTx, error) {
+	if limits.MaxBytes > 0 && incoming.size > limits.MaxBytes {
+		return nil, fmt.Errorf("%w: tx of %d bytes exceeds the pool limit of %d bytes", mempool.ErrMempoolTxMaxCapacity, incoming.size, limits.MaxBytes)
+	}
+
+	isFreed := make(map[string]bool, len(freed))
+	for _, hash := range freed {
+		isFreed[hash] = true
+	}
+
+	count, bytes := len(p.txs), p.bytes
+	fits := func() bool {
+		return (limits.MaxTxs <= 0 || count < limits.MaxTxs) &&
+			(limits.MaxBytes <= 0 || bytes+incoming.size <= limits.MaxBytes)
+	}
+
+	remaining := make(map[int]thTx, len(p.txs))
+	for i, ttx := range p.txs {
+		if isFreed[ttx.hash] {
+			count--
+			bytes -= ttx.size
+			continue
+		}
+		remaining[i] = ttx
+	}
+	if fits() {
+		return nil, nil
+	}
+
+	var victims []thTx
+	for !fits() {
+		tails := make(map[string]uint64)
+		for _, ttx := range remaining {
+			for _, s := range ttx.signers {
+				if seq, ok := tails[s.address]; !ok || s.sequence > seq {
+					tails[s.address] = s.sequence
+				}
+			}
+		}
+
+		// Lowest priority evictable tx, the most recent arrival on ties
+		victim := -1
+		for i, ttx := range remaining {
+			if !evictable(ttx, tails, incoming) {
+				continue
+			}
+			if victim < 0 || ttx.priority < remaining[victim].priority ||
+				(ttx.priority == remaining[victim].priority && i > victim) {
+				victim = i
+			}
+		}
+		if victim < 0 || remaining[victim].priority >= incoming.priority {
+			return nil, fmt.Errorf("%w: no lower priority tx to evict", mempool.ErrMempoolTxMaxCapacity)
+		}
+
+		victims = append(victims, remaining[victim])
+		count--
+		bytes -= remaining[victim].size
+		delete(remaining, victim)
+	}
+
+	return victims, nil
+}
+
+// evictable reports whether ttx is the last tx of all of its signers and
+// shares none with incoming, whose own txs it would leave with a gap.
+func evictable(ttx thTx, tails map[string]uint64, incoming thTx) bool {
+	for _, s := range ttx.signers {
+		if tails[s.address] != s.sequence || incoming.hasSigner(s.address) {
+			return false
+		}
+	}
+	return true
+}
+
+// Expire records height as the last committed height and drops txs that have
+// been in their pool for more than the configured TTL, returning the number
+// dropped. Pending txs expire if no vote extension reported them in time and
+// ready txs if no proposal included them.
+func (t *ThresholdMempool) Expire(height int64) int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.height = height
+	t.pruneBuckets()
+	if t.ttl <= 0 {
+		return 0
+	}
+
+	var expired []string
+	for _, p := range []*thTxs{&t.pendingPool, &t.pool, &t.defaultPool} {
+		for i := range p.txs {
+			// Txs inserted before the first commit seen start their TTL now
+			if p.txs[i].height == 0 {
+				p.txs[i].height = height
+			}
+			if height-p.txs[i].height > t.ttl {
+				expired = append(expired, p.txs[i].hash)
+			}
+		}
+	}
+
+	for _, hash := range expired {
+		t.evict(hash, EvictExpired)
+	}
+	t.reportSize()
+
+	return len(expired)
+}
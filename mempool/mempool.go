@@ -3,142 +3,498 @@ package mempool
 import (
 	"context"
 	"cosmossdk.io/log"
+	"cosmossdk.io/math"
+	"errors"
 	"fmt"
+	"github.com/cometbft/cometbft/crypto/tmhash"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/mempool"
 	"github.com/cosmos/cosmos-sdk/x/auth/signing"
+	"sync"
 )
 
 var _ mempool.Mempool = (*ThresholdMempool)(nil)
 
+// ErrTxAlreadyExists is returned when inserting a tx whose hash is already
+// tracked in either pool.
+var ErrTxAlreadyExists = errors.New("tx already exists in mempool")
+
+type poolKind int
+
+const (
+	pendingKind poolKind = iota
+	readyKind
+	defaultKind
+)
+
+// ThresholdMempool is safe for concurrent use. CheckTx, ExtendVote and
+// PrepareProposal run on different ABCI connections and may call into the
+// mempool at the same time.
+//
+// Txs are identified by the hash of their encoded bytes, the same hash
+// CometBFT reports for a tx.
 type ThresholdMempool struct {
+	mtx         sync.RWMutex
 	logger      log.Logger
+	txEncoder   sdk.TxEncoder
+	priority    PriorityFunc
+	bump        math.LegacyDec
+	index       map[string]poolKind
+	senders     map[string]map[uint64]string
 	pendingPool thTxs
 	pool        thTxs
+	// defaultPool holds txs outside the threshold lane, see WithLanes
+	defaultPool   thTxs
+	thresholdLane MatchFunc
+
+	pendingLimits PoolLimits
+	readyLimits   PoolLimits
+	defaultLimits PoolLimits
+	// ttl is the number of blocks a tx may stay in either pool, 0 disables it
+	ttl int64
+	// height is the last committed height seen by Expire
+	height  int64
+	evicted map[string]uint64
+	// wal, when set, records every change so the mempool survives restarts
+	wal *WAL
+
+	senderLimits SenderLimits
+	buckets      map[string]*tokenBucket
 }
 
-func NewThresholdMempool(logger log.Logger) *ThresholdMempool {
-	return &ThresholdMempool{
-		logger: logger.With("module", "threshold-mempool"),
+// Option configures optional ThresholdMempool behaviour
+type Option func(*ThresholdMempool)
+
+func NewThresholdMempool(logger log.Logger, txEncoder sdk.TxEncoder, opts ...Option) *ThresholdMempool {
+	mp := &ThresholdMempool{
+		logger:    logger.With("module", "threshold-mempool"),
+		txEncoder: txEncoder,
+		priority:  FeePerGasPriority,
+		bump:      DefaultReplacementBump,
+		index:     make(map[string]poolKind),
+		senders:   make(map[string]map[uint64]string),
+		evicted:   make(map[string]uint64),
+		buckets:   make(map[string]*tokenBucket),
+
+		pendingLimits: DefaultPoolLimits,
+		readyLimits:   DefaultPoolLimits,
+		defaultLimits: DefaultPoolLimits,
+		ttl:           DefaultTTL,
 	}
+	for _, opt := range opts {
+		opt(mp)
+	}
+	return mp
+}
+
+// TxHash returns the mempool key of the encoded tx bz
+func TxHash(bz []byte) []byte {
+	return tmhash.Sum(bz)
 }
 
 func (t *ThresholdMempool) Insert(ctx context.Context, tx sdk.Tx) error {
-	sigs, err := tx.(signing.SigVerifiableTx).GetSignaturesV2()
+	signers, err := txSigners(tx)
 	if err != nil {
-		t.logger.Error("Error unable to retrieve tx signatures")
+		t.logger.Error(fmt.Sprintf("Error unable to retrieve tx signers: %v", err))
 		return err
 	}
-	// Guarantee there is at least 1 signer
-	if len(sigs) == 0 {
-		t.logger.Error("Error missing tx signatures")
-		return fmt.Errorf("Transaction must be signed")
+
+	hash, bz, err := t.hashTx(tx)
+	if err != nil {
+		t.logger.Error(fmt.Sprintf("Error unable to hash tx: %v", err))
+		return err
 	}
 
-	sig := sigs[0]
-	sender := sdk.AccAddress(sig.PubKey.Address()).String()
+	sender := signers[0].address
 	t.logger.Info(fmt.Sprintf("This is the sender account address :: %v", sender))
 
-	// Set default 0 priority
-	priority := int64(0)
+	priority := t.priority(tx)
 	appTx := thTx{
-		sender,
-		priority,
-		tx,
+		address:  sender,
+		signers:  signers,
+		priority: priority,
+		hash:     hash,
+		size:     int64(len(bz)),
+		tx:       tx,
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if _, ok := t.index[hash]; ok {
+		return ErrTxAlreadyExists
+	}
+
+	// A tx reusing a signer's sequence replaces the existing txs if it pays enough
+	replaced := t.conflicts(signers)
+	for _, existing := range replaced {
+		if err := checkReplacement(t.get(existing).tx, tx, t.bump); err != nil {
+			return err
+		}
+	}
+
+	// Txs outside the threshold lane skip the vote extension round
+	kind, limits := pendingKind, t.pendingLimits
+	if t.thresholdLane != nil && !t.thresholdLane(tx) {
+		kind, limits = defaultKind, t.defaultLimits
+	}
+	if kind == pendingKind {
+		if err := t.checkSenderLimits(signers, replaced); err != nil {
+			t.logger.Info(fmt.Sprintf("Rejecting transaction from %v: %v", sender, err))
+			return err
+		}
+	}
+
+	victims, err := evictionPlan(t.poolOf(kind), limits, appTx, replaced)
+	if err != nil {
+		t.logger.Info(fmt.Sprintf("Rejecting transaction from %v: %v", sender, err))
+		return err
+	}
+
+	for _, existing := range replaced {
+		t.logger.Info(fmt.Sprintf("Replacing transaction from %v with sequence %v", t.get(existing).address, t.get(existing).signers[0].sequence))
+		t.evict(existing, EvictReplaced)
+	}
+	for _, victim := range victims {
+		t.logger.Info(fmt.Sprintf("Evicting transaction from %v with priority %v, pool full", victim.address, victim.priority))
+		t.evict(victim.hash, EvictCapacity)
 	}
 
 	t.logger.Info(fmt.Sprintf("Inserting transaction from %v with priority %v", sender, priority))
 
-	t.pendingPool.txs = append(t.pendingPool.txs, appTx)
+	appTx.height = t.height
+	t.poolOf(kind).push(appTx)
+	t.index[hash] = kind
+	for _, s := range signers {
+		if t.senders[s.address] == nil {
+			t.senders[s.address] = make(map[uint64]string)
+		}
+		t.senders[s.address][s.sequence] = hash
+	}
+	t.logWAL(walInsert, bz)
+	if kind == pendingKind {
+		t.takeTokens(signers)
+	}
 	leng := len(t.pendingPool.txs)
 	t.logger.Info(fmt.Sprintf("Transactions length %v", leng))
+	t.reportSize()
 
 	return nil
 }
 
-func (t *ThresholdMempool) Select(ctx context.Context, i [][]byte) mempool.Iterator {
-	if len(t.pool.txs) == 0 {
-		return nil
+// txSigners returns the signers of tx with the sequence each one signed,
+// in GetSigners order.
+func txSigners(tx sdk.Tx) ([]txSigner, error) {
+	sigTx, ok := tx.(signing.SigVerifiableTx)
+	if !ok {
+		return nil, fmt.Errorf("tx of type %T does not carry signatures", tx)
+	}
+	addrs, err := sigTx.GetSigners()
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := sigTx.GetSignaturesV2()
+	if err != nil {
+		return nil, err
+	}
+	// Guarantee there is at least 1 signer
+	if len(sigs) == 0 || len(addrs) == 0 {
+		return nil, fmt.Errorf("Transaction must be signed")
+	}
+	if len(sigs) != len(addrs) {
+		return nil, fmt.Errorf("tx has %d signers but %d signatures", len(addrs), len(sigs))
+	}
+
+	signers := make([]txSigner, len(addrs))
+	for i, addr := range addrs {
+		signers[i] = txSigner{
+			address:  sdk.AccAddress(addr).String(),
+			sequence: sigs[i].Sequence,
+		}
 	}
+	return signers, nil
+}
+
+// conflicts returns the hashes of txs already holding one of signers'
+// sequences. Callers must hold the lock.
+func (t *ThresholdMempool) conflicts(signers []txSigner) []string {
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, s := range signers {
+		if hash, ok := t.senders[s.address][s.sequence]; ok && !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// Contains reports whether a tx with the given hash, see TxHash, is in any
+// of the pools
+func (t *ThresholdMempool) Contains(hash []byte) bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	_, ok := t.index[string(hash)]
+	return ok
+}
+
+// Select returns an independent iterator over a snapshot of the ready pool,
+// highest priority first with each sender's txs in sequence order. Txs whose
+// encoding matches one of exclude are skipped, as are txs whose sender has
+// one with a lower sequence in the pending pool or the default lane. Txs
+// inserted, promoted or removed after the call are not reflected in the
+// iterator.
+func (t *ThresholdMempool) Select(ctx context.Context, exclude [][]byte) mempool.Iterator {
+	t.mtx.RLock()
+	snapshot := t.pool.snapshot()
+	snapshot.txs = t.holdBack(snapshot.txs, pendingKind, defaultKind)
+	t.mtx.RUnlock()
+
+	snapshot.txs = orderByPriority(snapshot.txs)
+	return iterator(snapshot, exclude)
+}
 
-	return &t.pool
+// SelectDefault returns an independent iterator over a snapshot of the
+// default lane, ordered like Select. It is empty unless WithLanes is set.
+// Txs whose sender has one with a lower sequence in the pending or ready
+// pool are skipped, a proposal may leave that one out for lack of auction
+// lane space.
+func (t *ThresholdMempool) SelectDefault(ctx context.Context, exclude [][]byte) mempool.Iterator {
+	t.mtx.RLock()
+	snapshot := t.defaultPool.snapshot()
+	snapshot.txs = t.holdBack(snapshot.txs, pendingKind, readyKind)
+	t.mtx.RUnlock()
+
+	snapshot.txs = orderByPriority(snapshot.txs)
+	return iterator(snapshot, exclude)
 }
 
-func (t *ThresholdMempool) SelectPending(ctx context.Context, i [][]byte) mempool.Iterator {
-	if len(t.pendingPool.txs) == 0 {
+// SelectPending returns an independent iterator over a snapshot of the
+// pending pool in arrival order, with each sender's txs in sequence order.
+// Txs whose sender has one with a lower sequence in the default lane are
+// skipped. Callers may promote txs with Update while iterating.
+func (t *ThresholdMempool) SelectPending(ctx context.Context, exclude [][]byte) mempool.Iterator {
+	t.mtx.RLock()
+	snapshot := t.pendingPool.snapshot()
+	snapshot.txs = t.holdBack(snapshot.txs, defaultKind)
+	t.mtx.RUnlock()
+
+	snapshot.txs = orderBySequence(snapshot.txs)
+	return iterator(snapshot, exclude)
+}
+
+// iterator drops excluded txs from snapshot and returns nil when nothing is
+// left, as the mempool.Iterator contract expects.
+func iterator(snapshot *thTxs, exclude [][]byte) mempool.Iterator {
+	if len(exclude) > 0 {
+		excluded := make(map[string]struct{}, len(exclude))
+		for _, bz := range exclude {
+			excluded[string(TxHash(bz))] = struct{}{}
+		}
+
+		filtered := snapshot.txs[:0]
+		for _, ttx := range snapshot.txs {
+			if _, ok := excluded[ttx.hash]; !ok {
+				filtered = append(filtered, ttx)
+			}
+		}
+		snapshot.txs = filtered
+	}
+
+	if len(snapshot.txs) == 0 {
 		return nil
 	}
 
-	return &t.pendingPool
+	return snapshot
 }
 
+// Update promotes tx from the pending pool to the ready pool. If the ready
+// pool is full and tx cannot displace a lower priority tx, it stays pending.
 func (t *ThresholdMempool) Update(ctx context.Context, tx sdk.Tx) error {
-	sigs, err := tx.(signing.SigVerifiableTx).GetSignaturesV2()
+	hash, _, err := t.hashTx(tx)
 	if err != nil {
 		return err
 	}
-	if len(sigs) == 0 {
-		return fmt.Errorf("tx must have at least one signer")
-	}
 
-	sig := sigs[0]
-	sender := sdk.AccAddress(sig.PubKey.Address()).String()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
 
-	txToUpdate := thTx{
-		sender,
-		1,
-		tx,
+	if kind, ok := t.index[hash]; !ok || kind != pendingKind {
+		return mempool.ErrTxNotFound
 	}
 
-	for idx, ttx := range t.pendingPool.txs {
-		if ttx.Equal(txToUpdate) {
-			t.pendingPool.txs = removeAtIndex(t.pendingPool.txs, idx)
-			t.pool.txs = append(t.pool.txs, txToUpdate)
-			return nil
-		}
+	idx := t.pendingPool.find(hash)
+	ttx := t.pendingPool.txs[idx]
+
+	victims, err := evictionPlan(&t.pool, t.readyLimits, ttx, nil)
+	if err != nil {
+		return err
+	}
+	for _, victim := range victims {
+		t.logger.Info(fmt.Sprintf("Evicting transaction from %v with priority %v, ready pool full", victim.address, victim.priority))
+		t.evict(victim.hash, EvictCapacity)
 	}
-	// remove from pendingPool, add to
 
-	return mempool.ErrTxNotFound
+	t.pendingPool.removeAt(idx)
+	ttx.height = t.height
+	t.pool.push(ttx)
+	t.index[hash] = readyKind
+	t.logWAL(walPromote, []byte(hash))
+	t.reportSize()
+
+	return nil
 }
 
 func (t *ThresholdMempool) CountTx() int {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
 	return len(t.pendingPool.txs)
 }
 
+// Remove drops tx from whichever pool holds it. BaseApp calls Remove for every
+// tx included in a block and for txs that fail recheck.
 func (t *ThresholdMempool) Remove(tx sdk.Tx) error {
-	sigs, err := tx.(signing.SigVerifiableTx).GetSignaturesV2()
+	hash, _, err := t.hashTx(tx)
 	if err != nil {
 		return err
 	}
-	if len(sigs) == 0 {
-		return fmt.Errorf("tx must have at least one signer")
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if !t.remove(hash) {
+		return mempool.ErrTxNotFound
 	}
+	t.reportSize()
 
-	sig := sigs[0]
-	sender := sdk.AccAddress(sig.PubKey.Address()).String()
+	return nil
+}
 
-	txToRemove := thTx{
-		sender,
-		1,
-		tx,
+// RecheckFunc reports why tx is no longer valid against the state in ctx
+type RecheckFunc func(ctx sdk.Context, tx sdk.Tx) error
+
+// Recheck runs check against every tx in both pools and evicts the txs it
+// rejects, returning the number evicted. check runs without holding the
+// mempool lock, so it may be slow without blocking CheckTx.
+func (t *ThresholdMempool) Recheck(ctx sdk.Context, check RecheckFunc) int {
+	t.mtx.RLock()
+	txs := append(t.pendingPool.snapshot().txs, t.pool.txs...)
+	txs = append(txs, t.defaultPool.txs...)
+	t.mtx.RUnlock()
+
+	var stale []thTx
+	for _, ttx := range txs {
+		if err := check(ctx, ttx.tx); err != nil {
+			t.logger.Info(fmt.Sprintf("Evicting transaction from %v on recheck: %v", ttx.address, err))
+			stale = append(stale, ttx)
+		}
 	}
 
-	for idx, ttx := range t.pool.txs {
-		if ttx.Equal(txToRemove) {
-			t.pool.txs = removeAtIndex(t.pool.txs, idx)
-			return nil
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	evicted := 0
+	for _, ttx := range stale {
+		// The tx may have been removed while check was running
+		if t.evict(ttx.hash, EvictRecheck) {
+			evicted++
 		}
 	}
+	t.reportSize()
 
-	return mempool.ErrTxNotFound
+	return evicted
+}
+
+// get returns the tx with the given hash, which must be in the index.
+// Callers must hold the lock.
+func (t *ThresholdMempool) get(hash string) thTx {
+	p := t.poolOf(t.index[hash])
+	return p.txs[p.find(hash)]
+}
+
+// remove drops the tx with the given hash from its pool. Callers must hold
+// the write lock.
+func (t *ThresholdMempool) remove(hash string) bool {
+	kind, ok := t.index[hash]
+	if !ok {
+		return false
+	}
+
+	p := t.poolOf(kind)
+	ttx := p.removeAt(p.find(hash))
+	delete(t.index, hash)
+	t.logWAL(walRemove, []byte(hash))
+
+	for _, signer := range ttx.signers {
+		if seqs := t.senders[signer.address]; seqs[signer.sequence] == hash {
+			delete(seqs, signer.sequence)
+			if len(seqs) == 0 {
+				delete(t.senders, signer.address)
+			}
+		}
+	}
+
+	return true
+}
+
+func (t *ThresholdMempool) poolOf(kind poolKind) *thTxs {
+	switch kind {
+	case readyKind:
+		return &t.pool
+	case defaultKind:
+		return &t.defaultPool
+	default:
+		return &t.pendingPool
+	}
+}
+
+// hashTx returns the mempool key of tx with its encoded bytes
+func (t *ThresholdMempool) hashTx(tx sdk.Tx) (string, []byte, error) {
+	bz, err := t.txEncoder(tx)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(TxHash(bz)), bz, nil
 }
 
 var _ mempool.Iterator = &thTxs{}
 
 type thTxs struct {
-	idx int
-	txs []thTx
+	idx   int
+	txs   []thTx
+	bytes int64
+}
+
+func (t *thTxs) push(ttx thTx) {
+	t.txs = append(t.txs, ttx)
+	t.bytes += ttx.size
+}
+
+func (t *thTxs) removeAt(idx int) thTx {
+	ttx := t.txs[idx]
+	t.txs = removeAtIndex(t.txs, idx)
+	t.bytes -= ttx.size
+	return ttx
+}
+
+// snapshot copies the pool's txs into a new iterator positioned at the start
+func (t *thTxs) snapshot() *thTxs {
+	txs := make([]thTx, len(t.txs))
+	copy(txs, t.txs)
+	return &thTxs{txs: txs}
+}
+
+// find returns the position of the tx with the given hash. Callers check the
+// index first, so the tx is known to be present.
+func (t *thTxs) find(hash string) int {
+	for idx, ttx := range t.txs {
+		if ttx.hash == hash {
+			return idx
+		}
+	}
+	panic(fmt.Sprintf("mempool index out of sync: tx %X not found", hash))
 }
 
 func (t *thTxs) Next() mempool.Iterator {
@@ -163,27 +519,40 @@ func (t *thTxs) Tx() sdk.Tx {
 }
 
 type thTx struct {
+	// address is the first signer, the account the tx is attributed to
 	address  string
+	signers  []txSigner
 	priority int64
-	tx       sdk.Tx
+	hash     string
+	size     int64
+	// height is the last committed height when the tx entered its pool
+	height int64
+	tx     sdk.Tx
 }
 
-func (tx thTx) Equal(other thTx) bool {
-	if tx.address != other.address {
-		return false
-	}
-
-	if len(tx.tx.GetMsgs()) != len(other.tx.GetMsgs()) {
-		return false
+// sequenceOf returns the sequence signed by address
+func (tx thTx) sequenceOf(address string) uint64 {
+	for _, s := range tx.signers {
+		if s.address == address {
+			return s.sequence
+		}
 	}
+	return 0
+}
 
-	for i, msg := range tx.tx.GetMsgs() {
-		if msg.String() != other.tx.GetMsgs()[i].String() {
-			return false
+// hasSigner reports whether address signed tx
+func (tx thTx) hasSigner(address string) bool {
+	for _, s := range tx.signers {
+		if s.address == address {
+			return true
 		}
 	}
+	return false
+}
 
-	return true
+type txSigner struct {
+	address  string
+	sequence uint64
 }
 
 func removeAtIndex[T any](slice []T, index int) []T {
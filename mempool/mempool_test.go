@@ -3,11 +3,14 @@ package mempool
 import (
 	"context"
 	"cosmossdk.io/log"
+	"cosmossdk.io/math"
 	"fmt"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkmempool "github.com/cosmos/cosmos-sdk/types/mempool"
 	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
 	"github.com/stretchr/testify/require"
 	"math/rand"
+	"sync"
 	"testing"
 )
 
@@ -42,7 +45,7 @@ func TestThresholdMempool(t *testing.T) {
 	for i, tc := range tests {
 		t.Run(fmt.Sprintf("Test Case %d", i), func(t *testing.T) {
 			logger := log.NewTestLogger(t)
-			pool := NewThresholdMempool(logger)
+			pool := NewThresholdMempool(logger, testTxEncoder)
 			for j, tt := range tc.txs {
 				tx := testTx{
 					id:      j,
@@ -98,3 +101,579 @@ func TestThresholdMempool(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectPendingSnapshot(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 5)
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+	for i, acc := range accounts {
+		err := pool.Insert(context.Background(), testTx{id: i, address: acc.Address, nonce: uint64(i)})
+		require.NoError(t, err)
+	}
+
+	// Promoting txs while iterating must visit every pending tx exactly once
+	var visited []int
+	itr := pool.SelectPending(context.Background(), nil)
+	for itr != nil {
+		tx := itr.Tx()
+		visited = append(visited, tx.(testTx).id)
+		require.NoError(t, pool.Update(context.Background(), tx))
+		itr = itr.Next()
+	}
+
+	require.Equal(t, []int{0, 1, 2, 3, 4}, visited)
+	require.Equal(t, 0, pool.CountTx())
+	require.Nil(t, pool.SelectPending(context.Background(), nil))
+}
+
+func TestThresholdMempoolConcurrency(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 10)
+	pool := NewThresholdMempool(log.NewNopLogger(), testTxEncoder)
+
+	const txsPerAccount = 50
+	var wg sync.WaitGroup
+	// require may only fail the test from its own goroutine
+	errs := make(chan error, len(accounts)*txsPerAccount)
+
+	// CheckTx
+	for i, acc := range accounts {
+		wg.Add(1)
+		go func(i int, addr sdk.AccAddress) {
+			defer wg.Done()
+			for n := 0; n < txsPerAccount; n++ {
+				errs <- pool.Insert(context.Background(), testTx{id: i*txsPerAccount + n, address: addr, nonce: uint64(n)})
+			}
+		}(i, acc.Address)
+	}
+
+	// ExtendVote promotes pending txs
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 100; n++ {
+			for itr := pool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+				_ = pool.Update(context.Background(), itr.Tx())
+			}
+		}
+	}()
+
+	// PrepareProposal reads and removes ready txs
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 100; n++ {
+			for itr := pool.Select(context.Background(), nil); itr != nil; itr = itr.Next() {
+				_ = pool.Remove(itr.Tx())
+			}
+			_ = pool.CountTx()
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Drain whatever is left so every inserted tx is accounted for
+	for itr := pool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+		require.NoError(t, pool.Update(context.Background(), itr.Tx()))
+	}
+	require.Equal(t, 0, pool.CountTx())
+}
+
+func TestSelectIndependentIterators(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+	for i, acc := range accounts {
+		tx := testTx{id: i, address: acc.Address, nonce: uint64(i)}
+		require.NoError(t, pool.Insert(context.Background(), tx))
+		require.NoError(t, pool.Update(context.Background(), tx))
+	}
+
+	ids := func(exclude [][]byte) []int {
+		var res []int
+		for itr := pool.Select(context.Background(), exclude); itr != nil; itr = itr.Next() {
+			res = append(res, itr.Tx().(testTx).id)
+		}
+		return res
+	}
+
+	// Each call starts from the beginning of the pool
+	require.Equal(t, []int{0, 1, 2}, ids(nil))
+	require.Equal(t, []int{0, 1, 2}, ids(nil))
+
+	// Advancing one iterator does not move another
+	first := pool.Select(context.Background(), nil)
+	second := pool.Select(context.Background(), nil)
+	first = first.Next().Next()
+	require.Equal(t, 2, first.Tx().(testTx).id)
+	require.Equal(t, 0, second.Tx().(testTx).id)
+	require.Nil(t, first.Next())
+
+	// Txs passed to Select are excluded
+	require.Equal(t, []int{0, 2}, ids([][]byte{[]byte("tx-1")}))
+	require.Nil(t, pool.Select(context.Background(), [][]byte{[]byte("tx-0"), []byte("tx-1"), []byte("tx-2")}))
+	require.Equal(t, []int{0, 1, 2}, ids(nil))
+}
+
+func TestTxIdentity(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 1)
+	alice := accounts[0].Address
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+
+	// Distinct txs with identical msgs from the same sender are tracked separately
+	first := testTx{id: 0, address: alice, nonce: 0}
+	second := testTx{id: 1, address: alice, nonce: 1}
+	require.NoError(t, pool.Insert(context.Background(), first))
+	require.NoError(t, pool.Insert(context.Background(), second))
+	require.Equal(t, 2, pool.CountTx())
+
+	// Re-inserting the same tx is rejected
+	require.ErrorIs(t, pool.Insert(context.Background(), first), ErrTxAlreadyExists)
+	require.Equal(t, 2, pool.CountTx())
+
+	hash := func(tx testTx) []byte {
+		bz, err := testTxEncoder(tx)
+		require.NoError(t, err)
+		return TxHash(bz)
+	}
+	require.True(t, pool.Contains(hash(first)))
+	require.True(t, pool.Contains(hash(second)))
+	require.False(t, pool.Contains(hash(testTx{id: 2, address: alice})))
+
+	// Promoting one leaves the other pending
+	require.NoError(t, pool.Update(context.Background(), second))
+	require.ErrorIs(t, pool.Update(context.Background(), second), sdkmempool.ErrTxNotFound)
+	require.True(t, pool.Contains(hash(second)))
+	require.Equal(t, 1, pool.CountTx())
+	itr := pool.SelectPending(context.Background(), nil)
+	require.Equal(t, 0, itr.Tx().(testTx).id)
+	require.Nil(t, itr.Next())
+
+	// Txs are removed from whichever pool holds them
+	require.NoError(t, pool.Remove(first))
+	require.NoError(t, pool.Remove(second))
+	require.ErrorIs(t, pool.Remove(second), sdkmempool.ErrTxNotFound)
+	require.False(t, pool.Contains(hash(first)))
+	require.False(t, pool.Contains(hash(second)))
+	require.Nil(t, pool.SelectPending(context.Background(), nil))
+	require.Nil(t, pool.Select(context.Background(), nil))
+
+	// A removed tx may be inserted again
+	require.NoError(t, pool.Insert(context.Background(), second))
+}
+
+func TestRecheck(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+
+	txs := []testTx{
+		{id: 0, address: alice, nonce: 0},
+		{id: 1, address: alice, nonce: 1},
+		{id: 2, address: bob, nonce: 0},
+		{id: 3, address: bob, nonce: 1},
+	}
+	for _, tx := range txs {
+		require.NoError(t, pool.Insert(context.Background(), tx))
+	}
+	require.NoError(t, pool.Update(context.Background(), txs[2]))
+
+	// Both of alice's txs and bob's ready tx were committed
+	committed := map[string]uint64{alice.String(): 2, bob.String(): 1}
+	evicted := pool.Recheck(sdk.Context{}, func(_ sdk.Context, tx sdk.Tx) error {
+		ttx := tx.(testTx)
+		if ttx.nonce < committed[ttx.address.String()] {
+			return fmt.Errorf("stale nonce")
+		}
+		return nil
+	})
+	require.Equal(t, 3, evicted)
+
+	require.Equal(t, 1, pool.CountTx())
+	require.Nil(t, pool.Select(context.Background(), nil))
+	itr := pool.SelectPending(context.Background(), nil)
+	require.Equal(t, 3, itr.Tx().(testTx).id)
+	require.Nil(t, itr.Next())
+}
+
+func TestSelectPriorityOrder(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	cindy := accounts[2].Address
+
+	tests := []struct {
+		name  string
+		txs   []testTx
+		order []int
+	}{
+		{
+			name: "highest gas price first",
+			txs: []testTx{
+				{id: 0, address: alice, fee: 10},
+				{id: 1, address: bob, fee: 30},
+				{id: 2, address: cindy, fee: 20},
+			},
+			order: []int{1, 2, 0},
+		},
+		{
+			name: "equal priority keeps arrival order",
+			txs: []testTx{
+				{id: 0, address: alice, fee: 10},
+				{id: 1, address: bob, fee: 10},
+				{id: 2, address: cindy, fee: 10},
+			},
+			order: []int{0, 1, 2},
+		},
+		{
+			name: "sender sequence order is preserved",
+			txs: []testTx{
+				{id: 0, address: alice, nonce: 1, fee: 50},
+				{id: 1, address: bob, nonce: 0, fee: 20},
+				{id: 2, address: alice, nonce: 0, fee: 10},
+				{id: 3, address: alice, nonce: 2, fee: 30},
+			},
+			// alice's nonce 0 holds back her higher paying nonce 1
+			order: []int{1, 2, 0, 3},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+			for _, tx := range tc.txs {
+				tx.fee *= 10 // gas is 10, keep the gas price equal to fee
+				require.NoError(t, pool.Insert(context.Background(), tx))
+				require.NoError(t, pool.Update(context.Background(), tx))
+			}
+
+			var order []int
+			for itr := pool.Select(context.Background(), nil); itr != nil; itr = itr.Next() {
+				order = append(order, itr.Tx().(testTx).id)
+			}
+			require.Equal(t, tc.order, order)
+		})
+	}
+}
+
+func TestFeePerGasPriority(t *testing.T) {
+	require.Equal(t, int64(0), FeePerGasPriority(testTx{fee: 5}))
+	require.Equal(t, int64(3), FeePerGasPriority(testTx{fee: 30}))
+
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithPriority(func(tx sdk.Tx) int64 {
+		return int64(-tx.(testTx).id)
+	}))
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	for i, acc := range accounts {
+		tx := testTx{id: i, address: acc.Address}
+		require.NoError(t, pool.Insert(context.Background(), tx))
+		require.NoError(t, pool.Update(context.Background(), tx))
+	}
+	require.Equal(t, 0, pool.Select(context.Background(), nil).Tx().(testTx).id)
+}
+
+func TestReplaceByFee(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+
+	original := testTx{id: 0, address: alice, nonce: 1, fee: 100}
+	require.NoError(t, pool.Insert(context.Background(), original))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 1, address: bob, nonce: 1, fee: 100}))
+
+	// Below the default 10% bump
+	err := pool.Insert(context.Background(), testTx{id: 2, address: alice, nonce: 1, fee: 109})
+	require.ErrorIs(t, err, ErrReplacementUnderpriced)
+	require.Equal(t, 2, pool.CountTx())
+
+	// A different sequence is not a replacement
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 3, address: alice, nonce: 0, fee: 1}))
+	require.Equal(t, 3, pool.CountTx())
+
+	replacement := testTx{id: 4, address: alice, nonce: 1, fee: 110}
+	require.NoError(t, pool.Insert(context.Background(), replacement))
+	require.Equal(t, 3, pool.CountTx())
+
+	hash := func(tx testTx) []byte {
+		bz, err := testTxEncoder(tx)
+		require.NoError(t, err)
+		return TxHash(bz)
+	}
+	require.False(t, pool.Contains(hash(original)))
+	require.True(t, pool.Contains(hash(replacement)))
+
+	// Pending txs keep arrival order except that alice's are in sequence order
+	var order []int
+	for itr := pool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+		order = append(order, itr.Tx().(testTx).id)
+	}
+	require.Equal(t, []int{1, 3, 4}, order)
+
+	// Ready txs can be replaced too, the replacement waits in the pending pool
+	require.NoError(t, pool.Update(context.Background(), replacement))
+	bigger := testTx{id: 5, address: alice, nonce: 1, fee: 1000}
+	require.NoError(t, pool.Insert(context.Background(), bigger))
+	require.False(t, pool.Contains(hash(replacement)))
+	require.Nil(t, pool.Select(context.Background(), nil))
+
+	// Once removed, the sequence is free again
+	require.NoError(t, pool.Remove(bigger))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 6, address: alice, nonce: 1, fee: 1}))
+
+	// The bump is configurable
+	strict := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithReplacementBump(math.LegacyOneDec()))
+	require.NoError(t, strict.Insert(context.Background(), testTx{id: 0, address: alice, fee: 100}))
+	require.ErrorIs(t, strict.Insert(context.Background(), testTx{id: 1, address: alice, fee: 150}), ErrReplacementUnderpriced)
+	require.NoError(t, strict.Insert(context.Background(), testTx{id: 2, address: alice, fee: 200}))
+}
+
+func TestCapacityEviction(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 4)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	cindy := accounts[2].Address
+	dave := accounts[3].Address
+
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder,
+		WithPendingLimits(PoolLimits{MaxTxs: 3}),
+		WithReadyLimits(PoolLimits{MaxTxs: 1}),
+	)
+	insert := func(tx testTx) error {
+		tx.fee *= 10 // gas is 10, keep the gas price equal to fee
+		return pool.Insert(context.Background(), tx)
+	}
+
+	require.NoError(t, insert(testTx{id: 0, address: alice, nonce: 0, fee: 1}))
+	require.NoError(t, insert(testTx{id: 1, address: alice, nonce: 1, fee: 5}))
+	require.NoError(t, insert(testTx{id: 2, address: bob, nonce: 0, fee: 3}))
+
+	// Full, and nothing pays less than an equal priority tx
+	require.ErrorIs(t, insert(testTx{id: 3, address: cindy, fee: 3}), sdkmempool.ErrMempoolTxMaxCapacity)
+
+	// Only sender tails may be evicted, so alice's cheap nonce 0 survives and
+	// bob's tx, the lowest priority tail, makes room
+	require.NoError(t, insert(testTx{id: 4, address: cindy, fee: 4}))
+	var pending []int
+	for itr := pool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+		pending = append(pending, itr.Tx().(testTx).id)
+	}
+	require.Equal(t, []int{0, 1, 4}, pending)
+	require.Equal(t, uint64(1), pool.Stats().Evicted[EvictCapacity])
+
+	// Promotion into a full ready pool evicts a lower priority ready tx
+	require.NoError(t, pool.Update(context.Background(), testTx{id: 0, address: alice, nonce: 0, fee: 10}))
+	require.NoError(t, pool.Update(context.Background(), testTx{id: 4, address: cindy, fee: 40}))
+	stats := pool.Stats()
+	require.Equal(t, 1, stats.ReadyTxs)
+	require.Equal(t, 1, stats.PendingTxs)
+	require.Equal(t, uint64(2), stats.Evicted[EvictCapacity])
+
+	// A lower priority tx stays pending when it cannot displace a ready tx
+	require.NoError(t, insert(testTx{id: 6, address: dave, fee: 2}))
+	require.ErrorIs(t, pool.Update(context.Background(), testTx{id: 6, address: dave, fee: 20}), sdkmempool.ErrMempoolTxMaxCapacity)
+	require.Equal(t, 2, pool.CountTx())
+
+	// Byte limits
+	small := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithPendingLimits(PoolLimits{MaxBytes: 8}))
+	require.NoError(t, small.Insert(context.Background(), testTx{id: 1, address: alice}))
+	require.Equal(t, int64(4), small.Stats().PendingBytes)
+	require.ErrorIs(t, small.Insert(context.Background(), testTx{id: 100000, address: bob, fee: 100}), sdkmempool.ErrMempoolTxMaxCapacity)
+	require.NoError(t, small.Insert(context.Background(), testTx{id: 2, address: bob, fee: 100}))
+	require.NoError(t, small.Insert(context.Background(), testTx{id: 3, address: cindy, fee: 200}))
+	require.Equal(t, int64(8), small.Stats().PendingBytes)
+	require.Equal(t, 2, small.CountTx())
+}
+
+func TestExpire(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithTTL(2))
+
+	// Inserted before any commit was seen, the TTL starts at the first one
+	early := testTx{id: 0, address: accounts[0].Address}
+	require.NoError(t, pool.Insert(context.Background(), early))
+	require.Equal(t, 0, pool.Expire(10))
+
+	// Inserted after height 10 was committed
+	pending := testTx{id: 1, address: accounts[1].Address}
+	ready := testTx{id: 2, address: accounts[2].Address}
+	require.NoError(t, pool.Insert(context.Background(), pending))
+	require.NoError(t, pool.Insert(context.Background(), ready))
+
+	require.Equal(t, 0, pool.Expire(11))
+	// Promotion restarts the TTL in the ready pool
+	require.NoError(t, pool.Update(context.Background(), ready))
+	require.Equal(t, 0, pool.Expire(12))
+
+	// early and pending have waited more than 2 blocks
+	require.Equal(t, 2, pool.Expire(13))
+	require.Equal(t, 0, pool.CountTx())
+	require.NotNil(t, pool.Select(context.Background(), nil))
+
+	require.Equal(t, 1, pool.Expire(14))
+	require.Nil(t, pool.Select(context.Background(), nil))
+
+	stats := pool.Stats()
+	require.Equal(t, uint64(3), stats.Evicted[EvictExpired])
+	require.Equal(t, 0, stats.ReadyTxs)
+	require.Equal(t, int64(0), stats.ReadyBytes+stats.PendingBytes)
+
+	// A TTL of 0 keeps txs forever
+	forever := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithTTL(0))
+	require.NoError(t, forever.Insert(context.Background(), early))
+	require.Equal(t, 0, forever.Expire(1000))
+	require.Equal(t, 1, forever.CountTx())
+}
+
+func TestMultiSignerTxs(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	cindy := accounts[2].Address
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+
+	insert := func(tx testTx) error {
+		tx.fee *= 10 // gas is 10, keep the gas price equal to fee
+		if err := pool.Insert(context.Background(), tx); err != nil {
+			return err
+		}
+		return pool.Update(context.Background(), tx)
+	}
+
+	// bob co-signs alice's tx at his nonce 1, after his own nonce 0
+	require.NoError(t, insert(testTx{id: 0, address: alice, nonce: 0, fee: 50, cosigners: []testSigner{{bob, 1}}}))
+	require.NoError(t, insert(testTx{id: 1, address: bob, nonce: 0, fee: 10}))
+	require.NoError(t, insert(testTx{id: 2, address: cindy, nonce: 0, fee: 20}))
+	require.NoError(t, insert(testTx{id: 3, address: bob, nonce: 2, fee: 90}))
+
+	var order []int
+	for itr := pool.Select(context.Background(), nil); itr != nil; itr = itr.Next() {
+		order = append(order, itr.Tx().(testTx).id)
+	}
+	require.Equal(t, []int{2, 1, 0, 3}, order)
+
+	// The co-signer's sequence is tracked too, reusing it is a replacement
+	err := pool.Insert(context.Background(), testTx{id: 4, address: cindy, nonce: 1, fee: 10, cosigners: []testSigner{{bob, 1}}})
+	require.ErrorIs(t, err, ErrReplacementUnderpriced)
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 5, address: cindy, nonce: 1, fee: 1000, cosigners: []testSigner{{bob, 1}}}))
+	require.Equal(t, uint64(1), pool.Stats().Evicted[EvictReplaced])
+
+	// Removing a tx frees the sequence of every signer
+	require.NoError(t, pool.Remove(testTx{id: 5}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 6, address: cindy, nonce: 1}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 7, address: alice, nonce: 1, cosigners: []testSigner{{bob, 1}}}))
+}
+
+func TestLanes(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	// Odd ids stand in for bids
+	isBid := func(tx sdk.Tx) bool { return tx.(testTx).id%2 == 1 }
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithLanes(isBid))
+
+	bid := testTx{id: 1, address: alice, nonce: 0, fee: 10}
+	send := testTx{id: 2, address: bob, nonce: 0, fee: 10}
+	require.NoError(t, pool.Insert(context.Background(), bid))
+	require.NoError(t, pool.Insert(context.Background(), send))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 4, address: bob, nonce: 1, fee: 50}))
+
+	// Only the bid waits for a vote extension
+	require.Equal(t, 1, pool.CountTx())
+	itr := pool.SelectPending(context.Background(), nil)
+	require.Equal(t, bid, itr.Tx())
+	require.Nil(t, itr.Next())
+	require.Nil(t, pool.Select(context.Background(), nil))
+
+	// Default lane txs are proposable straight away, in sequence order
+	var order []int
+	for itr := pool.SelectDefault(context.Background(), nil); itr != nil; itr = itr.Next() {
+		order = append(order, itr.Tx().(testTx).id)
+	}
+	require.Equal(t, []int{2, 4}, order)
+	require.ErrorIs(t, pool.Update(context.Background(), send), sdkmempool.ErrTxNotFound)
+
+	require.NoError(t, pool.Update(context.Background(), bid))
+	require.Equal(t, bid, pool.Select(context.Background(), nil).Tx())
+
+	stats := pool.Stats()
+	require.Equal(t, 1, stats.ReadyTxs)
+	require.Equal(t, 2, stats.DefaultTxs)
+
+	// Removal and replacement work across lanes
+	require.NoError(t, pool.Remove(send))
+	require.ErrorIs(t, pool.Insert(context.Background(), testTx{id: 6, address: alice, nonce: 0, fee: 10}), ErrReplacementUnderpriced)
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 6, address: alice, nonce: 0, fee: 20}))
+	require.Nil(t, pool.Select(context.Background(), nil))
+	require.Equal(t, 2, pool.Stats().DefaultTxs)
+}
+
+func TestLanesOrderSenderBySequence(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	carol := accounts[2].Address
+	isBid := func(tx sdk.Tx) bool { return tx.(testTx).id%2 == 1 }
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithLanes(isBid))
+
+	ids := func(itr sdkmempool.Iterator) []int {
+		var res []int
+		for ; itr != nil; itr = itr.Next() {
+			res = append(res, itr.Tx().(testTx).id)
+		}
+		return res
+	}
+
+	// Alice's send waits for her earlier bid to be committed, a proposal may
+	// leave out the ready bid
+	bid := testTx{id: 1, address: alice, nonce: 0, fee: 10}
+	require.NoError(t, pool.Insert(context.Background(), bid))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 2, address: alice, nonce: 1, fee: 10}))
+	require.Empty(t, ids(pool.SelectDefault(context.Background(), nil)))
+	require.NoError(t, pool.Update(context.Background(), bid))
+	require.Equal(t, []int{1}, ids(pool.Select(context.Background(), nil)))
+	require.Empty(t, ids(pool.SelectDefault(context.Background(), nil)))
+
+	// Bob's bid waits for his earlier send, and a cosigner counts as a sender
+	// too
+	send := testTx{id: 4, address: bob, nonce: 0, fee: 10}
+	require.NoError(t, pool.Insert(context.Background(), send))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 3, address: bob, nonce: 1, fee: 10}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 5, address: carol, nonce: 0, fee: 10, cosigners: []testSigner{{bob, 2}}}))
+	require.Empty(t, ids(pool.SelectPending(context.Background(), nil)))
+	require.Equal(t, []int{4}, ids(pool.SelectDefault(context.Background(), nil)))
+
+	// A ready tx waits for an earlier one in the default lane, and still once
+	// that is replaced by a pending bid
+	later := testTx{id: 7, address: alice, nonce: 2, fee: 10}
+	require.NoError(t, pool.Insert(context.Background(), later))
+	require.NoError(t, pool.Update(context.Background(), later))
+	require.Equal(t, []int{1}, ids(pool.Select(context.Background(), nil)))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 9, address: alice, nonce: 1, fee: 20}))
+	require.Equal(t, []int{1}, ids(pool.Select(context.Background(), nil)))
+	require.Equal(t, []int{4}, ids(pool.SelectDefault(context.Background(), nil)))
+
+	// Once committed, the sender's next txs are selected
+	require.NoError(t, pool.Remove(send))
+	require.Equal(t, []int{3, 5, 9}, ids(pool.SelectPending(context.Background(), nil)))
+}
+
+func TestSequenceOrder(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	carol := accounts[2].Address
+
+	// Alice's txs are out of sequence, the others keep their place
+	txs := []sdk.Tx{
+		testTx{id: 0, address: alice, nonce: 2},
+		testTx{id: 1, address: bob, nonce: 0},
+		testTx{id: 2, address: alice, nonce: 1},
+		testTx{id: 3, address: carol, nonce: 0, cosigners: []testSigner{{alice, 0}}},
+		testTx{id: 4, address: bob, nonce: 1},
+	}
+	require.Equal(t, []int{1, 3, 2, 0, 4}, SequenceOrder(txs))
+	require.Empty(t, SequenceOrder(nil))
+}
@@ -0,0 +1,71 @@
+package mempool
+
+import (
+	"github.com/cosmos/cosmos-sdk/telemetry"
+)
+
+// Reasons a tx is evicted from the mempool, used as metric keys
+const (
+	EvictCapacity = "capacity"
+	EvictExpired  = "expired"
+	EvictRecheck  = "recheck"
+	EvictReplaced = "replaced"
+)
+
+const metricsPrefix = "threshold_mempool"
+
+// Stats is a point in time view of the mempool
+type Stats struct {
+	PendingTxs   int
+	PendingBytes int64
+	ReadyTxs     int
+	ReadyBytes   int64
+	DefaultTxs   int
+	DefaultBytes int64
+	// Evicted counts evicted txs by reason since the node started
+	Evicted map[string]uint64
+	// Height is the last committed height seen by the mempool
+	Height int64
+}
+
+func (t *ThresholdMempool) Stats() Stats {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	evicted := make(map[string]uint64, len(t.evicted))
+	for reason, n := range t.evicted {
+		evicted[reason] = n
+	}
+
+	return Stats{
+		PendingTxs:   len(t.pendingPool.txs),
+		PendingBytes: t.pendingPool.bytes,
+		ReadyTxs:     len(t.pool.txs),
+		ReadyBytes:   t.pool.bytes,
+		DefaultTxs:   len(t.defaultPool.txs),
+		DefaultBytes: t.defaultPool.bytes,
+		Evicted:      evicted,
+		Height:       t.height,
+	}
+}
+
+// evict removes the tx with the given hash and counts it under reason.
+// Callers must hold the write lock.
+func (t *ThresholdMempool) evict(hash string, reason string) bool {
+	if !t.remove(hash) {
+		return false
+	}
+	t.evicted[reason]++
+	telemetry.IncrCounter(1, metricsPrefix, "evicted", reason)
+	return true
+}
+
+// reportSize publishes the pool sizes. Callers must hold the lock.
+func (t *ThresholdMempool) reportSize() {
+	telemetry.SetGauge(float32(len(t.pendingPool.txs)), metricsPrefix, "pending", "txs")
+	telemetry.SetGauge(float32(t.pendingPool.bytes), metricsPrefix, "pending", "bytes")
+	telemetry.SetGauge(float32(len(t.pool.txs)), metricsPrefix, "ready", "txs")
+	telemetry.SetGauge(float32(t.pool.bytes), metricsPrefix, "ready", "bytes")
+	telemetry.SetGauge(float32(len(t.defaultPool.txs)), metricsPrefix, "default", "txs")
+	telemetry.SetGauge(float32(t.defaultPool.bytes), metricsPrefix, "default", "bytes")
+}
@@ -0,0 +1,172 @@
+package mempool
+
+import (
+	"container/heap"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"math"
+	"sort"
+)
+
+// PriorityFunc assigns a priority to a tx when it enters the mempool. Ready
+// txs with a higher priority are selected first.
+type PriorityFunc func(tx sdk.Tx) int64
+
+// WithPriority sets the function used to order the ready pool
+func WithPriority(fn PriorityFunc) Option {
+	return func(t *ThresholdMempool) {
+		t.priority = fn
+	}
+}
+
+// FeePerGasPriority prioritises txs by gas price, taking the lowest price
+// across fee denoms as the SDK fee checker does.
+func FeePerGasPriority(tx sdk.Tx) int64 {
+	feeTx, ok := tx.(sdk.FeeTx)
+	if !ok || feeTx.GetGas() == 0 {
+		return 0
+	}
+
+	gas := feeTx.GetGas()
+	if gas > math.MaxInt64 {
+		gas = math.MaxInt64
+	}
+
+	var priority int64
+	for _, c := range feeTx.GetFee() {
+		p := int64(math.MaxInt64)
+		gasPrice := c.Amount.QuoRaw(int64(gas))
+		if gasPrice.IsInt64() {
+			p = gasPrice.Int64()
+		}
+		if priority == 0 || p < priority {
+			priority = p
+		}
+	}
+
+	return priority
+}
+
+// orderByPriority orders txs by descending priority while keeping each
+// signer's txs in sequence order. A tx competes on its own priority once it
+// is next in sequence for all of its signers, so a low priority tx holds back
+// its signers' later txs. Equal priorities keep the order of txs.
+func orderByPriority(txs []thTx) []thTx {
+	return orderBySigners(txs, true)
+}
+
+// orderBySequence keeps the order of txs except that each signer's txs are
+// moved into sequence order.
+func orderBySequence(txs []thTx) []thTx {
+	return orderBySigners(txs, false)
+}
+
+// SequenceOrder returns the indices of txs in the order orderBySequence puts
+// them, for callers merging txs from several selections into one proposal.
+// Txs whose signers cannot be read are not held to any order but their own.
+func SequenceOrder(txs []sdk.Tx) []int {
+	ttxs := make([]thTx, len(txs))
+	for i, tx := range txs {
+		signers, _ := txSigners(tx)
+		ttxs[i] = thTx{tx: tx, signers: signers}
+	}
+	return signerOrder(ttxs, false)
+}
+
+func orderBySigners(txs []thTx, byPriority bool) []thTx {
+	ordered := make([]thTx, 0, len(txs))
+	for _, i := range signerOrder(txs, byPriority) {
+		ordered = append(ordered, txs[i])
+	}
+	return ordered
+}
+
+// signerOrder returns the indices of txs in the order orderBySigners puts them
+func signerOrder(txs []thTx, byPriority bool) []int {
+	nodes := make([]*orderedTx, len(txs))
+	chains := make(map[string][]*orderedTx)
+	for i, ttx := range txs {
+		nodes[i] = &orderedTx{thTx: ttx, arrival: i}
+		for _, s := range ttx.signers {
+			chains[s.address] = append(chains[s.address], nodes[i])
+		}
+	}
+
+	// Link each tx to the next tx in sequence of every one of its signers
+	for addr, chain := range chains {
+		sort.SliceStable(chain, func(i, j int) bool {
+			return chain[i].sequenceOf(addr) < chain[j].sequenceOf(addr)
+		})
+		for i := 1; i < len(chain); i++ {
+			chain[i-1].next = append(chain[i-1].next, chain[i])
+			chain[i].waiting++
+		}
+	}
+
+	queue := &txQueue{byPriority: byPriority}
+	for _, n := range nodes {
+		if n.waiting == 0 {
+			queue.txs = append(queue.txs, n)
+		}
+	}
+	heap.Init(queue)
+
+	ordered := make([]int, 0, len(txs))
+	for queue.Len() > 0 {
+		n := heap.Pop(queue).(*orderedTx)
+		n.done = true
+		ordered = append(ordered, n.arrival)
+		for _, next := range n.next {
+			next.waiting--
+			if next.waiting == 0 {
+				heap.Push(queue, next)
+			}
+		}
+	}
+
+	// Txs whose signers' sequences conflict can never be ordered, keep them last
+	for _, n := range nodes {
+		if !n.done {
+			ordered = append(ordered, n.arrival)
+		}
+	}
+
+	return ordered
+}
+
+type orderedTx struct {
+	thTx
+	arrival int
+	// next are the txs that follow this one in some signer's sequence
+	next []*orderedTx
+	// waiting counts the signers for which an earlier tx is not yet ordered
+	waiting int
+	done    bool
+}
+
+// txQueue is a heap of orderable txs, highest priority first when
+// byPriority is set and earliest arrival otherwise.
+type txQueue struct {
+	txs        []*orderedTx
+	byPriority bool
+}
+
+func (q *txQueue) Len() int { return len(q.txs) }
+
+func (q *txQueue) Less(i, j int) bool {
+	a, b := q.txs[i], q.txs[j]
+	if q.byPriority && a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.arrival < b.arrival
+}
+
+func (q *txQueue) Swap(i, j int) { q.txs[i], q.txs[j] = q.txs[j], q.txs[i] }
+
+func (q *txQueue) Push(x any) { q.txs = append(q.txs, x.(*orderedTx)) }
+
+func (q *txQueue) Pop() any {
+	old := q.txs
+	n := old[len(old)-1]
+	q.txs = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,126 @@
+package mempool
+
+import (
+	"context"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	mempoolv1 "github.com/fatal-fruit/cosmapp/mempool/types"
+	"sort"
+)
+
+// Lanes reported by the query service
+const (
+	LaneAuction = "auction"
+	LaneDefault = "default"
+)
+
+// ObservedFunc reports whether a tx was seen in the last committed vote
+// extensions
+type ObservedFunc func(tx sdk.Tx) bool
+
+type queryServer struct {
+	mempool  *ThresholdMempool
+	observed ObservedFunc
+}
+
+var _ mempoolv1.QueryServer = queryServer{}
+
+// NewQueryServer returns the mempool inspection service. observed may be nil,
+// in which case txs are never reported as observed.
+func NewQueryServer(mp *ThresholdMempool, observed ObservedFunc) mempoolv1.QueryServer {
+	return queryServer{mempool: mp, observed: observed}
+}
+
+func (q queryServer) Pending(_ context.Context, _ *mempoolv1.QueryPendingRequest) (*mempoolv1.QueryPendingResponse, error) {
+	txs, height := q.mempool.inspect(pendingKind)
+	infos := make([]*mempoolv1.TxInfo, 0, len(txs))
+	for _, ttx := range txs {
+		infos = append(infos, q.txInfo(ttx, pendingKind, height))
+	}
+	return &mempoolv1.QueryPendingResponse{Txs: infos, Height: height}, nil
+}
+
+func (q queryServer) Ready(_ context.Context, _ *mempoolv1.QueryReadyRequest) (*mempoolv1.QueryReadyResponse, error) {
+	ready, height := q.mempool.inspect(readyKind)
+	lane, _ := q.mempool.inspect(defaultKind)
+	infos := make([]*mempoolv1.TxInfo, 0, len(ready)+len(lane))
+	for _, ttx := range ready {
+		infos = append(infos, q.txInfo(ttx, readyKind, height))
+	}
+	for _, ttx := range lane {
+		infos = append(infos, q.txInfo(ttx, defaultKind, height))
+	}
+	return &mempoolv1.QueryReadyResponse{Txs: infos, Height: height}, nil
+}
+
+func (q queryServer) Stats(_ context.Context, _ *mempoolv1.QueryStatsRequest) (*mempoolv1.QueryStatsResponse, error) {
+	stats := q.mempool.Stats()
+
+	reasons := make([]string, 0, len(stats.Evicted))
+	for reason := range stats.Evicted {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	evicted := make([]*mempoolv1.Eviction, 0, len(reasons))
+	for _, reason := range reasons {
+		evicted = append(evicted, &mempoolv1.Eviction{Reason: reason, Count: stats.Evicted[reason]})
+	}
+
+	return &mempoolv1.QueryStatsResponse{
+		PendingTxs:   int64(stats.PendingTxs),
+		PendingBytes: stats.PendingBytes,
+		ReadyTxs:     int64(stats.ReadyTxs),
+		ReadyBytes:   stats.ReadyBytes,
+		DefaultTxs:   int64(stats.DefaultTxs),
+		DefaultBytes: stats.DefaultBytes,
+		Evicted:      evicted,
+		Height:       stats.Height,
+	}, nil
+}
+
+// inspect returns the txs of a pool in the order they are selected, with the
+// last committed height seen by the mempool
+func (t *ThresholdMempool) inspect(kind poolKind) ([]thTx, int64) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	txs := t.poolOf(kind).snapshot().txs
+	if kind == pendingKind {
+		return orderBySequence(txs), t.height
+	}
+	return orderByPriority(txs), t.height
+}
+
+// laneOf returns the lane of the txs stored in a pool. Without WithLanes
+// every tx goes through the pending and ready pools, the auction lane.
+func laneOf(kind poolKind) string {
+	if kind == defaultKind {
+		return LaneDefault
+	}
+	return LaneAuction
+}
+
+// txInfo describes a tx stored in the pool of kind. A tx is only reported as
+// observed if it was seen in the last committed vote extensions, a ready tx
+// promoted at an earlier height may no longer be.
+func (q queryServer) txInfo(ttx thTx, kind poolKind, height int64) *mempoolv1.TxInfo {
+	signers := make([]string, len(ttx.signers))
+	for i, s := range ttx.signers {
+		signers[i] = s.address
+	}
+
+	var age int64
+	if ttx.height > 0 && height > ttx.height {
+		age = height - ttx.height
+	}
+
+	return &mempoolv1.TxInfo{
+		Hash:     fmt.Sprintf("%X", ttx.hash),
+		Signers:  signers,
+		Priority: ttx.priority,
+		Lane:     laneOf(kind),
+		Age:      age,
+		Observed: q.observed != nil && q.observed(ttx.tx),
+		Bytes:    ttx.size,
+	}
+}
@@ -0,0 +1,105 @@
+package mempool
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	mempoolv1 "github.com/fatal-fruit/cosmapp/mempool/types"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestQueryServer(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 3)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	cindy := accounts[2].Address
+	isBid := func(tx sdk.Tx) bool { return tx.(testTx).id < 10 }
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithLanes(isBid))
+	// Only the bid with id 2 made it into the last vote extensions, the one
+	// with id 1 was promoted at an earlier height
+	q := NewQueryServer(pool, func(tx sdk.Tx) bool { return tx.(testTx).id == 2 })
+
+	pool.Expire(5)
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 1, address: alice, nonce: 0, fee: 10}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 2, address: bob, nonce: 0, fee: 20}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 10, address: cindy, nonce: 0, fee: 30}))
+	pool.Expire(7)
+	require.NoError(t, pool.Update(context.Background(), testTx{id: 1}))
+
+	pending, err := q.Pending(context.Background(), &mempoolv1.QueryPendingRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), pending.Height)
+	require.Equal(t, []*mempoolv1.TxInfo{{
+		Hash:     fmt.Sprintf("%X", TxHash([]byte("tx-2"))),
+		Signers:  []string{bob.String()},
+		Priority: 2,
+		Lane:     LaneAuction,
+		Age:      2,
+		Observed: true,
+		Bytes:    4,
+	}}, pending.Txs)
+
+	ready, err := q.Ready(context.Background(), &mempoolv1.QueryReadyRequest{})
+	require.NoError(t, err)
+	require.Len(t, ready.Txs, 2)
+	require.Equal(t, LaneAuction, ready.Txs[0].Lane)
+	require.False(t, ready.Txs[0].Observed)
+	require.Equal(t, int64(0), ready.Txs[0].Age)
+	require.Equal(t, LaneDefault, ready.Txs[1].Lane)
+	require.False(t, ready.Txs[1].Observed)
+	require.Equal(t, int64(2), ready.Txs[1].Age)
+
+	// Responses survive the wire encoding used by the gRPC router
+	bz, err := ready.Marshal()
+	require.NoError(t, err)
+	var decoded mempoolv1.QueryReadyResponse
+	require.NoError(t, decoded.Unmarshal(bz))
+	require.Equal(t, ready, &decoded)
+
+	// Replace bob's pending bid
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 3, address: bob, nonce: 0, fee: 100}))
+	stats, err := q.Stats(context.Background(), &mempoolv1.QueryStatsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, &mempoolv1.QueryStatsResponse{
+		PendingTxs:   1,
+		PendingBytes: 4,
+		ReadyTxs:     1,
+		ReadyBytes:   4,
+		DefaultTxs:   1,
+		DefaultBytes: 5,
+		Evicted:      []*mempoolv1.Eviction{{Reason: EvictReplaced, Count: 1}},
+		Height:       7,
+	}, stats)
+}
+
+func TestQueryServerWithoutLanes(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder)
+	q := NewQueryServer(pool, func(tx sdk.Tx) bool { return tx.(testTx).id == 1 })
+
+	// Every tx goes through the vote extensions, whatever it carries
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 1, address: accounts[0].Address, nonce: 0, fee: 10}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 20, address: accounts[1].Address, nonce: 0, fee: 10}))
+	require.NoError(t, pool.Update(context.Background(), testTx{id: 1}))
+
+	ready, err := q.Ready(context.Background(), &mempoolv1.QueryReadyRequest{})
+	require.NoError(t, err)
+	require.Len(t, ready.Txs, 1)
+	require.Equal(t, LaneAuction, ready.Txs[0].Lane)
+	require.True(t, ready.Txs[0].Observed)
+
+	pending, err := q.Pending(context.Background(), &mempoolv1.QueryPendingRequest{})
+	require.NoError(t, err)
+	require.Len(t, pending.Txs, 1)
+	require.Equal(t, LaneAuction, pending.Txs[0].Lane)
+	require.False(t, pending.Txs[0].Observed)
+
+	// Nothing is reported as observed without the promoter
+	ready, err = NewQueryServer(pool, nil).Ready(context.Background(), &mempoolv1.QueryReadyRequest{})
+	require.NoError(t, err)
+	require.False(t, ready.Txs[0].Observed)
+}
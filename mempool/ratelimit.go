@@ -0,0 +1,136 @@
+package mempool
+
+import (
+	"fmt"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"math"
+)
+
+// Limits a SenderLimitError reports
+const (
+	LimitPendingTxs = "pending-txs"
+	LimitBidRate    = "bid-rate"
+)
+
+// DefaultSenderLimits are the limits applied by the node configuration. A
+// ThresholdMempool built without WithSenderLimits does not limit senders.
+var DefaultSenderLimits = SenderLimits{
+	MaxPendingTxs: 64,
+	BidsPerBlock:  8,
+	BidBurst:      16,
+}
+
+// SenderLimits bounds what a single signer may add to the threshold lane, so
+// one account cannot flood the pending pool and every validator's vote
+// extension. Every signer of a tx is held to the limits.
+type SenderLimits struct {
+	// MaxPendingTxs caps a signer's txs in the pending pool, 0 disables it
+	MaxPendingTxs int
+	// BidsPerBlock refills a signer's allowance of threshold lane txs every
+	// block, up to BidBurst. A zero BidsPerBlock disables the rate limit.
+	BidsPerBlock float64
+	BidBurst     int
+}
+
+// SenderLimitError is returned by Insert when a signer of the tx is over one
+// of its SenderLimits. It wraps the SDK's mempool full error, so CheckTx
+// reports it to the client with a registered code.
+type SenderLimitError struct {
+	Sender string
+	// Limit is LimitPendingTxs or LimitBidRate
+	Limit string
+}
+
+func (e *SenderLimitError) Error() string {
+	return fmt.Sprintf("sender %v exceeded its %v limit", e.Sender, e.Limit)
+}
+
+func (e *SenderLimitError) Unwrap() error { return sdkerrors.ErrMempoolIsFull }
+
+// WithSenderLimits limits what each signer may add to the threshold lane
+func WithSenderLimits(limits SenderLimits) Option {
+	return func(t *ThresholdMempool) {
+		t.senderLimits = limits
+	}
+}
+
+// tokenBucket holds a signer's allowance of threshold lane txs as of height
+type tokenBucket struct {
+	tokens float64
+	height int64
+}
+
+// checkSenderLimits returns a SenderLimitError if a signer cannot add another
+// tx to the pending pool. Txs in replaced are about to leave the pool and do
+// not count towards the cap. Callers must hold the lock.
+func (t *ThresholdMempool) checkSenderLimits(signers []txSigner, replaced []string) error {
+	for _, s := range signers {
+		if t.senderLimits.MaxPendingTxs > 0 && t.pendingCount(s.address, replaced) >= t.senderLimits.MaxPendingTxs {
+			return t.rejectSender(s.address, LimitPendingTxs)
+		}
+		if t.senderLimits.BidsPerBlock > 0 && t.tokens(s.address) < 1 {
+			return t.rejectSender(s.address, LimitBidRate)
+		}
+	}
+	return nil
+}
+
+func (t *ThresholdMempool) rejectSender(sender, limit string) error {
+	telemetry.IncrCounter(1, metricsPrefix, "rejected", limit)
+	return &SenderLimitError{Sender: sender, Limit: limit}
+}
+
+// pendingCount returns the number of pending txs signed by address, leaving
+// out those in excluded. Callers must hold the lock.
+func (t *ThresholdMempool) pendingCount(address string, excluded []string) int {
+	count := 0
+	for _, hash := range t.senders[address] {
+		if t.index[hash] != pendingKind {
+			continue
+		}
+		skip := false
+		for _, e := range excluded {
+			if e == hash {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			count++
+		}
+	}
+	return count
+}
+
+// tokens returns the allowance of address at the current height. Callers must
+// hold the lock.
+func (t *ThresholdMempool) tokens(address string) float64 {
+	bucket, ok := t.buckets[address]
+	if !ok {
+		return float64(t.senderLimits.BidBurst)
+	}
+	refilled := bucket.tokens + t.senderLimits.BidsPerBlock*float64(t.height-bucket.height)
+	return math.Min(refilled, float64(t.senderLimits.BidBurst))
+}
+
+// takeTokens charges every signer one token for a threshold lane tx. Callers
+// must hold the write lock.
+func (t *ThresholdMempool) takeTokens(signers []txSigner) {
+	if t.senderLimits.BidsPerBlock <= 0 {
+		return
+	}
+	for _, s := range signers {
+		t.buckets[s.address] = &tokenBucket{tokens: t.tokens(s.address) - 1, height: t.height}
+	}
+}
+
+// pruneBuckets forgets signers whose allowance has refilled, a missing bucket
+// is a full one. Callers must hold the write lock.
+func (t *ThresholdMempool) pruneBuckets() {
+	for address := range t.buckets {
+		if t.tokens(address) >= float64(t.senderLimits.BidBurst) {
+			delete(t.buckets, address)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package mempool
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	"errors"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func requireLimited(t *testing.T, err error, sender sdk.AccAddress, limit string) {
+	t.Helper()
+	var limitErr *SenderLimitError
+	require.True(t, errors.As(err, &limitErr), "unexpected error %v", err)
+	require.Equal(t, sender.String(), limitErr.Sender)
+	require.Equal(t, limit, limitErr.Limit)
+	require.ErrorIs(t, err, sdkerrors.ErrMempoolIsFull)
+}
+
+func TestSenderPendingCap(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithSenderLimits(SenderLimits{MaxPendingTxs: 2}))
+
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 0, address: alice, nonce: 0, fee: 10}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 1, address: alice, nonce: 1, fee: 10}))
+	requireLimited(t, pool.Insert(context.Background(), testTx{id: 2, address: alice, nonce: 2, fee: 10}), alice, LimitPendingTxs)
+
+	// A replacement does not add to the sender's pending txs
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 3, address: alice, nonce: 1, fee: 100}))
+
+	// Only pending txs count towards the cap
+	require.NoError(t, pool.Update(context.Background(), testTx{id: 0}))
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 2, address: alice, nonce: 2, fee: 10}))
+
+	// Co-signers are held to the cap too
+	err := pool.Insert(context.Background(), testTx{id: 4, address: bob, nonce: 0, fee: 10, cosigners: []testSigner{{alice, 3}}})
+	requireLimited(t, err, alice, LimitPendingTxs)
+	require.NoError(t, pool.Insert(context.Background(), testTx{id: 4, address: bob, nonce: 0, fee: 10}))
+}
+
+func TestSenderBidRate(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 1)
+	alice := accounts[0].Address
+	// Ids below 100 stand in for bids
+	isBid := func(tx sdk.Tx) bool { return tx.(testTx).id < 100 }
+	pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder,
+		WithLanes(isBid),
+		WithSenderLimits(SenderLimits{BidsPerBlock: 1, BidBurst: 2}),
+	)
+	pool.Expire(1)
+
+	nonce := uint64(0)
+	insert := func(id int) error {
+		err := pool.Insert(context.Background(), testTx{id: id, address: alice, nonce: nonce, fee: 10})
+		if err == nil {
+			nonce++
+		}
+		return err
+	}
+
+	// The burst is available straight away
+	require.NoError(t, insert(1))
+	require.NoError(t, insert(2))
+	requireLimited(t, insert(3), alice, LimitBidRate)
+
+	// Txs outside the threshold lane are not rate limited
+	require.NoError(t, insert(100))
+	require.NoError(t, pool.Remove(testTx{id: 100, address: alice, nonce: 2, fee: 10}))
+
+	// The allowance refills every block, up to the burst
+	pool.Expire(2)
+	require.NoError(t, insert(3))
+	requireLimited(t, insert(4), alice, LimitBidRate)
+
+	pool.Expire(10)
+	require.NoError(t, insert(4))
+	require.NoError(t, insert(5))
+	requireLimited(t, insert(6), alice, LimitBidRate)
+}
@@ -0,0 +1,60 @@
+package mempool
+
+import (
+	"cosmossdk.io/math"
+	"errors"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// DefaultReplacementBump is the minimum gas price increase, as a fraction of
+// the existing tx's gas price, a tx must pay to replace a tx with the
+// same sender and sequence.
+var DefaultReplacementBump = math.LegacyNewDecWithPrec(1, 1)
+
+// ErrReplacementUnderpriced is returned when a tx reuses a sender's sequence
+// without paying the minimum fee bump.
+var ErrReplacementUnderpriced = errors.New("replacement tx underpriced")
+
+// WithReplacementBump sets the minimum fee bump required to replace a tx
+func WithReplacementBump(bump math.LegacyDec) Option {
+	return func(t *ThresholdMempool) {
+		t.bump = bump
+	}
+}
+
+// checkReplacement returns an error unless replacement's gas price exceeds
+// old's by at least bump in every denom old pays fees in.
+func checkReplacement(old, replacement sdk.Tx, bump math.LegacyDec) error {
+	oldFeeTx, ok := old.(sdk.FeeTx)
+	if !ok {
+		return fmt.Errorf("%w: existing tx does not carry a fee", ErrReplacementUnderpriced)
+	}
+	newFeeTx, ok := replacement.(sdk.FeeTx)
+	if !ok {
+		return fmt.Errorf("%w: tx does not carry a fee", ErrReplacementUnderpriced)
+	}
+
+	oldFee, newFee := oldFeeTx.GetFee(), newFeeTx.GetFee()
+	if oldFee.IsZero() && newFee.IsZero() {
+		return fmt.Errorf("%w: tx must pay a fee to replace a tx without one", ErrReplacementUnderpriced)
+	}
+
+	multiplier := math.LegacyOneDec().Add(bump)
+	for _, c := range oldFee {
+		required := gasPrice(c.Amount, oldFeeTx.GetGas()).Mul(multiplier)
+		offered := gasPrice(newFee.AmountOf(c.Denom), newFeeTx.GetGas())
+		if offered.LT(required) {
+			return fmt.Errorf("%w: gas price %v%s is below the required %v%s", ErrReplacementUnderpriced, offered, c.Denom, required, c.Denom)
+		}
+	}
+
+	return nil
+}
+
+func gasPrice(amount math.Int, gas uint64) math.LegacyDec {
+	if gas == 0 {
+		return math.LegacyNewDecFromInt(amount)
+	}
+	return math.LegacyNewDecFromInt(amount).QuoInt(math.NewIntFromUint64(gas))
+}
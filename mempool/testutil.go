@@ -35,6 +35,14 @@ type testTx struct {
 	id      int
 	address sdk.AccAddress
 	nonce   uint64
+	fee     int64
+	// cosigners sign after address
+	cosigners []testSigner
+}
+
+type testSigner struct {
+	address sdk.AccAddress
+	nonce   uint64
 }
 
 func (tx testTx) GetMsgsV2() ([]proto.Message, error) {
@@ -42,16 +50,27 @@ func (tx testTx) GetMsgsV2() ([]proto.Message, error) {
 	panic("implement me")
 }
 
-func (tx testTx) GetSigners() ([][]byte, error) { panic("not implemented") }
+func (tx testTx) GetSigners() ([][]byte, error) {
+	signers := [][]byte{tx.address}
+	for _, s := range tx.cosigners {
+		signers = append(signers, s.address)
+	}
+	return signers, nil
+}
 
 func (tx testTx) GetPubKeys() ([]cryptotypes.PubKey, error) { panic("not implemented") }
 
 func (tx testTx) GetSignaturesV2() ([]txsigning.SignatureV2, error) {
-	return []txsigning.SignatureV2{{
+	sigs := []txsigning.SignatureV2{{
 		PubKey:   testPubKey{address: tx.address},
 		Data:     nil,
 		Sequence: tx.nonce,
-	}}, nil
+	}}
+	for _, s := range tx.cosigners {
+		// Signer pubkeys are unknown until an account's first tx is committed
+		sigs = append(sigs, txsigning.SignatureV2{Sequence: s.nonce})
+	}
+	return sigs, nil
 }
 
 func (tx testTx) GetGas() uint64 {
@@ -59,19 +78,20 @@ func (tx testTx) GetGas() uint64 {
 }
 
 func (tx testTx) GetFee() sdk.Coins {
-	return sdk.NewCoins(sdk.NewInt64Coin("stake", int64(5)))
+	return sdk.NewCoins(sdk.NewInt64Coin("stake", tx.fee))
 }
 
-func (tx testTx) FeePayer() sdk.AccAddress {
+func (tx testTx) FeePayer() []byte {
 	return tx.address
 }
 
-func (tx testTx) FeeGranter() sdk.AccAddress {
+func (tx testTx) FeeGranter() []byte {
 	return tx.address
 }
 
 var (
 	_ sdk.Tx                  = (*testTx)(nil)
+	_ sdk.FeeTx               = (*testTx)(nil)
 	_ signing.SigVerifiableTx = (*testTx)(nil)
 	_ cryptotypes.PubKey      = (*testPubKey)(nil)
 )
@@ -83,3 +103,12 @@ func (tx testTx) ValidateBasic() error { return nil }
 func (tx testTx) String() string {
 	return fmt.Sprintf("tx a: %s, n: %d", tx.address, tx.nonce)
 }
+
+// testTxEncoder encodes a testTx by its id, so txs sharing an id are duplicates
+func testTxEncoder(tx sdk.Tx) ([]byte, error) {
+	ttx, ok := tx.(testTx)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tx type %T", tx)
+	}
+	return []byte(fmt.Sprintf("tx-%d", ttx.id)), nil
+}
@@ -0,0 +1,298 @@
+package mempool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Ops recorded in the write-ahead log
+const (
+	walInsert byte = iota + 1
+	walPromote
+	walRemove
+)
+
+// walCompactMin is the number of records below which the log is never
+// compacted. Above it the log is rewritten once it holds four records for
+// every tx left in the mempool.
+const walCompactMin = 1024
+
+// WAL is an append-only log of mempool changes that lets a node restore its
+// mempool, including which txs were already promoted, after a restart.
+//
+// Each record is an op byte followed by a length prefixed payload: the
+// encoded tx for inserts and the tx hash for promotions and removals. Records
+// are flushed as they are written but not synced, so an OS crash may lose the
+// latest changes. A record cut short by a crash is ignored on replay.
+type WAL struct {
+	path    string
+	file    *os.File
+	w       *bufio.Writer
+	records int
+}
+
+// OpenWAL opens the log at path, creating it and its directory if needed
+func OpenWAL(path string) (*WAL, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &WAL{path: path, file: file, w: bufio.NewWriter(file)}, nil
+}
+
+func (w *WAL) Close() error {
+	if err := w.w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *WAL) append(op byte, payload []byte) error {
+	if err := writeRecord(w.w, op, payload); err != nil {
+		return err
+	}
+	w.records++
+	return w.w.Flush()
+}
+
+func writeRecord(w io.Writer, op byte, payload []byte) error {
+	rec := make([]byte, 0, 1+binary.MaxVarintLen64+len(payload))
+	rec = append(rec, op)
+	rec = binary.AppendUvarint(rec, uint64(len(payload)))
+	rec = append(rec, payload...)
+	_, err := w.Write(rec)
+	return err
+}
+
+// walEntry is a tx the log leaves in the mempool
+type walEntry struct {
+	bz       []byte
+	promoted bool
+}
+
+// entries replays the log and returns the txs left in the mempool, in the
+// order they were inserted
+func (w *WAL) entries() ([]walEntry, error) {
+	file, err := os.Open(w.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	type live struct {
+		walEntry
+		seq int
+	}
+	txs := make(map[string]*live)
+	var order []string
+	r := bufio.NewReader(file)
+	for {
+		op, payload, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// The last write was cut short, everything before it is intact
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case walInsert:
+			hash := string(TxHash(payload))
+			txs[hash] = &live{walEntry: walEntry{bz: payload}, seq: len(order)}
+			order = append(order, hash)
+		case walPromote:
+			if tx, ok := txs[string(payload)]; ok {
+				tx.promoted = true
+			}
+		case walRemove:
+			delete(txs, string(payload))
+		default:
+			return nil, fmt.Errorf("unknown mempool wal op %d", op)
+		}
+	}
+
+	var entries []walEntry
+	for seq, hash := range order {
+		// Skip txs removed since, or inserted again later
+		if tx, ok := txs[hash]; ok && tx.seq == seq {
+			entries = append(entries, tx.walEntry)
+		}
+	}
+	return entries, nil
+}
+
+func readRecord(r *bufio.Reader) (byte, []byte, error) {
+	op, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return op, payload, nil
+}
+
+// rewrite replaces the log with one inserting entries, and promoting those
+// that were promoted
+func (w *WAL) rewrite(entries []walEntry) error {
+	tmp := w.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(file)
+	records := 0
+	for _, e := range entries {
+		if err := writeRecord(bw, walInsert, e.bz); err != nil {
+			file.Close()
+			return err
+		}
+		records++
+		if e.promoted {
+			if err := writeRecord(bw, walPromote, TxHash(e.bz)); err != nil {
+				file.Close()
+				return err
+			}
+			records++
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return err
+	}
+	reopened, err := OpenWAL(w.path)
+	if err != nil {
+		return err
+	}
+	*w = *reopened
+	w.records = records
+	return nil
+}
+
+// WithWAL records every change to the mempool in w, see Restore
+func WithWAL(w *WAL) Option {
+	return func(t *ThresholdMempool) {
+		t.wal = w
+	}
+}
+
+// Restore inserts the txs left in the WAL, promotes those that were ready,
+// and compacts the log to match, returning the number of txs restored. It
+// must be called before the mempool is used. Restored txs have not been
+// checked against the current state and should be rechecked.
+//
+// Each sender's pending txs are held to the current MaxPendingTxs, which may
+// be lower than when they were admitted. The bid rate limit is not applied,
+// the txs were already charged for before the restart and the allowance does
+// not survive it.
+func (t *ThresholdMempool) Restore(txDecoder sdk.TxDecoder) (int, error) {
+	if t.wal == nil {
+		return 0, nil
+	}
+	entries, err := t.wal.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	// Replaying must not log the txs a second time
+	wal, limits := t.wal, t.senderLimits
+	t.wal, t.senderLimits = nil, SenderLimits{MaxPendingTxs: limits.MaxPendingTxs}
+	restored := 0
+	for _, e := range entries {
+		tx, err := txDecoder(e.bz)
+		if err != nil {
+			t.logger.Error(fmt.Sprintf("Unable to decode transaction from mempool WAL: %v", err))
+			continue
+		}
+		if err := t.Insert(context.Background(), tx); err != nil {
+			t.logger.Info(fmt.Sprintf("Unable to restore transaction from mempool WAL: %v", err))
+			continue
+		}
+		if e.promoted {
+			if err := t.Update(context.Background(), tx); err != nil {
+				t.logger.Info(fmt.Sprintf("Unable to restore promotion from mempool WAL: %v", err))
+			}
+		}
+		restored++
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.wal, t.senderLimits = wal, limits
+
+	return restored, t.compactWAL()
+}
+
+// logWAL records a change to the mempool. A failed write is logged rather
+// than failing the change, the WAL only speeds up recovery. Callers must hold
+// the write lock.
+func (t *ThresholdMempool) logWAL(op byte, payload []byte) {
+	if t.wal == nil {
+		return
+	}
+	if err := t.wal.append(op, payload); err != nil {
+		t.logger.Error(fmt.Sprintf("Unable to write mempool WAL: %v", err))
+		return
+	}
+	if t.wal.records > walCompactMin && t.wal.records > 4*len(t.index) {
+		if err := t.compactWAL(); err != nil {
+			t.logger.Error(fmt.Sprintf("Unable to compact mempool WAL: %v", err))
+		}
+	}
+}
+
+// compactWAL rewrites the log to hold only the txs now in the mempool.
+// Callers must hold the write lock.
+func (t *ThresholdMempool) compactWAL() error {
+	var entries []walEntry
+	for _, kind := range []poolKind{pendingKind, readyKind, defaultKind} {
+		for _, ttx := range t.poolOf(kind).txs {
+			bz, err := t.txEncoder(ttx.tx)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, walEntry{bz: bz, promoted: kind == readyKind})
+		}
+	}
+	return t.wal.rewrite(entries)
+}
@@ -0,0 +1,135 @@
+package mempool
+
+import (
+	"context"
+	"cosmossdk.io/log"
+	"fmt"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkmempool "github.com/cosmos/cosmos-sdk/types/mempool"
+	simtypes "github.com/cosmos/cosmos-sdk/types/simulation"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWALRestore(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 2)
+	alice := accounts[0].Address
+	bob := accounts[1].Address
+	path := filepath.Join(t.TempDir(), "data", "mempool.wal")
+
+	txs := map[string]testTx{}
+	decoder := func(bz []byte) (sdk.Tx, error) {
+		tx, ok := txs[string(bz)]
+		if !ok {
+			return nil, fmt.Errorf("unknown tx %s", bz)
+		}
+		return tx, nil
+	}
+	tx := func(id int, address sdk.AccAddress, nonce uint64) testTx {
+		ttx := testTx{id: id, address: address, nonce: nonce, fee: 10}
+		txs[fmt.Sprintf("tx-%d", id)] = ttx
+		return ttx
+	}
+	open := func() (*ThresholdMempool, *WAL, int) {
+		wal, err := OpenWAL(path)
+		require.NoError(t, err)
+		pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithWAL(wal))
+		restored, err := pool.Restore(decoder)
+		require.NoError(t, err)
+		return pool, wal, restored
+	}
+	ids := func(itr sdkmempool.Iterator) []int {
+		var ids []int
+		for ; itr != nil; itr = itr.Next() {
+			ids = append(ids, itr.Tx().(testTx).id)
+		}
+		return ids
+	}
+
+	pool, wal, restored := open()
+	require.Equal(t, 0, restored)
+	ready, removed, pending := tx(1, alice, 0), tx(2, bob, 0), tx(3, alice, 1)
+	for _, ttx := range []testTx{ready, removed, pending} {
+		require.NoError(t, pool.Insert(context.Background(), ttx))
+	}
+	require.NoError(t, pool.Update(context.Background(), ready))
+	require.NoError(t, pool.Remove(removed))
+	require.NoError(t, wal.Close())
+
+	// The restart keeps the pending and ready split
+	pool, wal, restored = open()
+	require.Equal(t, 2, restored)
+	require.Equal(t, []int{1}, ids(pool.Select(context.Background(), nil)))
+	require.Equal(t, []int{3}, ids(pool.SelectPending(context.Background(), nil)))
+	// The log was compacted to the two inserts and the promotion
+	require.Equal(t, 3, wal.records)
+
+	// A removal after the restart is logged too
+	require.NoError(t, pool.Remove(pending))
+	require.NoError(t, wal.Close())
+
+	// A record cut short by a crash is ignored
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{walInsert, 100, 't', 'x'})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	pool, wal, restored = open()
+	require.Equal(t, 1, restored)
+	require.Equal(t, []int{1}, ids(pool.Select(context.Background(), nil)))
+	require.Nil(t, pool.SelectPending(context.Background(), nil))
+
+	// Churn compacts the log instead of growing it without bound
+	for i := 0; i < walCompactMin; i++ {
+		churn := tx(100+i, bob, uint64(i))
+		require.NoError(t, pool.Insert(context.Background(), churn))
+		require.NoError(t, pool.Remove(churn))
+	}
+	require.Less(t, wal.records, walCompactMin)
+	require.NoError(t, wal.Close())
+
+	_, _, restored = open()
+	require.Equal(t, 1, restored)
+}
+
+func TestWALRestoreSenderLimits(t *testing.T) {
+	accounts := simtypes.RandomAccounts(rand.New(rand.NewSource(0)), 1)
+	alice := accounts[0].Address
+	path := filepath.Join(t.TempDir(), "mempool.wal")
+
+	txs := map[string]testTx{}
+	decoder := func(bz []byte) (sdk.Tx, error) {
+		return txs[string(bz)], nil
+	}
+	open := func(limits SenderLimits) (*ThresholdMempool, *WAL, int) {
+		wal, err := OpenWAL(path)
+		require.NoError(t, err)
+		pool := NewThresholdMempool(log.NewTestLogger(t), testTxEncoder, WithWAL(wal), WithSenderLimits(limits))
+		restored, err := pool.Restore(decoder)
+		require.NoError(t, err)
+		return pool, wal, restored
+	}
+
+	pool, wal, _ := open(SenderLimits{MaxPendingTxs: 4, BidsPerBlock: 1, BidBurst: 4})
+	for i := 0; i < 4; i++ {
+		ttx := testTx{id: i, address: alice, nonce: uint64(i), fee: 10}
+		txs[fmt.Sprintf("tx-%d", i)] = ttx
+		require.NoError(t, pool.Insert(context.Background(), ttx))
+	}
+	require.NoError(t, wal.Close())
+
+	// The allowance spent before the restart is not charged again, but a
+	// lower pending cap drops the sender's latest txs
+	pool, wal, restored := open(SenderLimits{MaxPendingTxs: 3, BidsPerBlock: 1, BidBurst: 4})
+	require.Equal(t, 3, restored)
+	var ids []int
+	for itr := pool.SelectPending(context.Background(), nil); itr != nil; itr = itr.Next() {
+		ids = append(ids, itr.Tx().(testTx).id)
+	}
+	require.Equal(t, []int{0, 1, 2}, ids)
+	require.NoError(t, wal.Close())
+}
@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+
+# Generates the gogoproto Go code for every .proto file under proto/. Requires
+# buf and protoc-gen-gocosmos (github.com/cosmos/gogoproto) on the PATH.
+
+set -eo pipefail
+
+cd proto
+buf mod update
+buf generate --template buf.gen.gogo.yaml
+cd ..
+
+cp -r github.com/fatal-fruit/cosmapp/* ./
+rm -rf github.com
@@ -3,4 +3,22 @@ package types
 var (
 	FlagValKey      = "val-key"
 	FlagRunProvider = "run-provider"
+
+	// app.toml keys
+	FlagMempoolPriority = "threshold-mempool.priority"
+	FlagReplacementBump = "threshold-mempool.replacement-bump"
+	FlagPendingMaxTxs   = "threshold-mempool.pending-max-txs"
+	FlagPendingMaxBytes = "threshold-mempool.pending-max-bytes"
+	FlagReadyMaxTxs     = "threshold-mempool.ready-max-txs"
+	FlagReadyMaxBytes   = "threshold-mempool.ready-max-bytes"
+	FlagMempoolTTL      = "threshold-mempool.ttl-blocks"
+	FlagLanes           = "threshold-mempool.lanes"
+	FlagAuctionShare    = "threshold-mempool.auction-lane-share"
+	FlagDefaultMaxTxs   = "threshold-mempool.default-max-txs"
+	FlagDefaultMaxBytes = "threshold-mempool.default-max-bytes"
+	FlagMempoolWAL      = "threshold-mempool.wal"
+	FlagSenderMaxTxs    = "threshold-mempool.sender-max-pending-txs"
+	FlagBidsPerBlock    = "threshold-mempool.bids-per-block"
+	FlagBidBurst        = "threshold-mempool.bid-burst"
+	FlagBidThreshold    = "vote-extensions.bid-threshold"
 )